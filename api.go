@@ -0,0 +1,240 @@
+package appsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+const subscribeTimeout = 30 * time.Second
+
+// Config represents the configuration of an API.
+// At most one of APIKey, Token and RequestSigner may be set.
+type Config struct {
+	// URL is the GraphQL endpoint URL.
+	URL string
+	// RealtimeURL overrides the realtime URL derived from URL.
+	RealtimeURL string
+	// Region is the region of the API, for custom domains.
+	Region string
+	// SigningHost is the API host to send requests to and authorize with APIKey or Token when URL is not the API host,
+	// such as a VPC endpoint.
+	// A RequestSigner signs for the host it is built with, see WithSigningHost.
+	SigningHost string
+
+	// APIKey enables API key authorization.
+	APIKey string
+	// Token enables OIDC or Amazon Cognito user pools authorization.
+	Token string
+	// RequestSigner enables IAM authorization, such as with NewSDKV2RequestSigner.
+	RequestSigner RequestSigner
+
+	// SubscriberID is the AppSync subscriber ID sent with MQTT subscription requests.
+	SubscriberID string
+	// GraphQLOptions are applied to the underlying graphql.Client.
+	GraphQLOptions []graphql.ClientOption
+	// OnConnectionLost is called when the shared realtime connection is lost.
+	OnConnectionLost func(err error)
+}
+
+// API is an AWS AppSync GraphQL API client for queries, mutations and pure websocket subscriptions.
+// All subscriptions share a single realtime connection, which is opened by the first subscription
+// and closed when the last one is stopped.
+type API struct {
+	endpoint         *Endpoint
+	client           *Client
+	header           http.Header
+	signer           RequestSigner
+	onConnectionLost func(err error)
+
+	mu   sync.Mutex
+	conn *realtimeConnection
+}
+
+// New returns an API instance configured with cfg.
+func New(cfg Config) (*API, error) {
+	var opts []EndpointOption
+	if len(cfg.Region) != 0 {
+		opts = append(opts, WithEndpointRegion(cfg.Region))
+	}
+	if len(cfg.RealtimeURL) != 0 {
+		opts = append(opts, WithRealtimeEndpoint(cfg.RealtimeURL))
+	}
+	endpoint, err := ResolveEndpoint(cfg.URL, opts...)
+	if err != nil {
+		slog.Error("unable to resolve endpoint", "error", err, "url", cfg.URL)
+		return nil, err
+	}
+
+	a := &API{
+		endpoint:         endpoint,
+		header:           http.Header{},
+		onConnectionLost: cfg.OnConnectionLost,
+	}
+	if a.onConnectionLost == nil {
+		a.onConnectionLost = func(error) {}
+	}
+
+	gopts := append([]graphql.ClientOption{}, cfg.GraphQLOptions...)
+	copts := []ClientOption{}
+	if len(cfg.SubscriberID) != 0 {
+		copts = append(copts, WithSubscriberID(cfg.SubscriberID))
+	}
+
+	host := endpoint.Host
+	if len(cfg.SigningHost) != 0 {
+		host = cfg.SigningHost
+		gopts = append(gopts, graphql.WithHTTPHeader(http.Header{"Host": {host}}))
+	}
+	modes := 0
+	if len(cfg.APIKey) != 0 {
+		modes++
+		gopts = append(gopts, graphql.WithAPIKey(cfg.APIKey))
+		a.header.Set("host", host)
+		a.header.Set("X-Api-Key", cfg.APIKey)
+	}
+	if len(cfg.Token) != 0 {
+		modes++
+		gopts = append(gopts, graphql.WithCredential(cfg.Token))
+		a.header.Set("host", host)
+		a.header.Set("Authorization", strings.TrimPrefix(cfg.Token, "Bearer "))
+	}
+	if cfg.RequestSigner != nil {
+		modes++
+		if len(cfg.SigningHost) != 0 {
+			return nil, errors.New("signing host cannot be applied to a RequestSigner, build it with WithSigningHost instead")
+		}
+		a.signer = cfg.RequestSigner
+		copts = append(copts, WithRequestSigner(a.signer))
+	}
+	if modes > 1 {
+		return nil, errors.New("only one of APIKey, Token and RequestSigner can be set")
+	}
+
+	a.client = NewClient(NewGraphQLClient(graphql.NewClient(endpoint.GraphQLURL, gopts...)), copts...)
+	return a, nil
+}
+
+// Endpoint returns the resolved endpoint of the API.
+func (a *API) Endpoint() Endpoint {
+	return *a.endpoint
+}
+
+// Client returns the underlying Client.
+func (a *API) Client() *Client {
+	return a.client
+}
+
+// Query runs a query.
+func (a *API) Query(request graphql.PostRequest) (*graphql.Response, error) {
+	if request.IsMutation() || request.IsSubscription() {
+		return nil, errors.New("the request is not a query")
+	}
+	return a.client.Post(request)
+}
+
+// Mutate runs a mutation.
+func (a *API) Mutate(request graphql.PostRequest) (*graphql.Response, error) {
+	if !request.IsMutation() {
+		return nil, errors.New("the request is not a mutation")
+	}
+	return a.client.Post(request)
+}
+
+// Subscribe starts a subscription on the shared realtime connection and returns the function stopping it.
+func (a *API) Subscribe(request graphql.PostRequest, onReceive func(response *graphql.Response)) (func(), error) {
+	if !request.IsSubscription() {
+		return nil, errors.New("the request is not a subscription")
+	}
+	brequest, err := json.Marshal(request)
+	if err != nil {
+		slog.Error("error marshalling request", "error", err, "request", request)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+
+	authz, err := authorizationHeaders(ctx, a.header, a.signer, brequest, false)
+	if err != nil {
+		return nil, err
+	}
+	// The subscription is registered while a.mu is held so that the connection is not closed meanwhile,
+	// but its start_ack is waited for without it.
+	a.mu.Lock()
+	conn, err := a.connection(ctx)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, err
+	}
+	id, s, err := conn.subscribe(brequest, authz, onReceive)
+	a.mu.Unlock()
+	if err == nil {
+		err = conn.waitStarted(ctx, id, s)
+	}
+	if err != nil {
+		slog.Error("error starting subscription", "error", err)
+		a.closeIfIdle(conn)
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { a.unsubscribe(conn, id) }) }, nil
+}
+
+// Close closes the shared realtime connection, ending every subscription.
+func (a *API) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.conn != nil {
+		a.conn.close()
+		a.conn = nil
+	}
+}
+
+// connection returns the shared realtime connection, connecting if needed. a.mu must be held.
+func (a *API) connection(ctx context.Context) (*realtimeConnection, error) {
+	if a.conn != nil && a.conn.alive() {
+		return a.conn, nil
+	}
+	bpayload := []byte("{}")
+	header, err := authorizationHeaders(ctx, a.header, a.signer, bpayload, true)
+	if err != nil {
+		return nil, err
+	}
+	bheader, err := json.Marshal(header)
+	if err != nil {
+		slog.Error("error marshalling headers", "error", err, "header", header)
+		return nil, err
+	}
+	conn, err := dialRealtimeConnection(ctx, a.endpoint.RealtimeURL, bheader, bpayload, a.onConnectionLost)
+	if err != nil {
+		return nil, err
+	}
+	a.conn = conn
+	return conn, nil
+}
+
+func (a *API) unsubscribe(conn *realtimeConnection, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+	conn.unsubscribe(ctx, id)
+	a.closeIfIdle(conn)
+}
+
+// closeIfIdle closes the shared connection when it has no subscriptions left.
+func (a *API) closeIfIdle(conn *realtimeConnection) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.conn == conn && conn.len() == 0 {
+		conn.close()
+		a.conn = nil
+	}
+}
@@ -0,0 +1,257 @@
+package appsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sdkv2_v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "api key",
+			cfg:  Config{URL: "https://example.appsync-api.us-east-1.amazonaws.com/graphql", APIKey: "da2-xxx"},
+		},
+		{
+			name: "token",
+			cfg:  Config{URL: "https://example.appsync-api.us-east-1.amazonaws.com/graphql", Token: "Bearer xxx"},
+		},
+		{
+			name: "iam",
+			cfg: Config{URL: "https://example.appsync-api.us-east-1.amazonaws.com/graphql",
+				RequestSigner: NewSDKV2RequestSigner(sdkv2_v4.NewSigner(), testCredentials, "us-east-1", "https://example.appsync-api.us-east-1.amazonaws.com/graphql")},
+		},
+		{
+			name: "request signer",
+			cfg:  Config{URL: "https://api.example.com/graphql", RequestSigner: fakeRequestSigner{}},
+		},
+		{
+			name:    "signing host with request signer",
+			cfg:     Config{URL: "https://vpce-0123.appsync-api.us-east-1.vpce.amazonaws.com/graphql", SigningHost: "example.appsync-api.us-east-1.amazonaws.com", RequestSigner: fakeRequestSigner{}},
+			wantErr: true,
+		},
+		{
+			name:    "api key and request signer",
+			cfg:     Config{URL: "https://example.appsync-api.us-east-1.amazonaws.com/graphql", APIKey: "da2-xxx", RequestSigner: fakeRequestSigner{}},
+			wantErr: true,
+		},
+		{
+			name:    "several auth modes",
+			cfg:     Config{URL: "https://example.appsync-api.us-east-1.amazonaws.com/graphql", APIKey: "da2-xxx", Token: "xxx"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			cfg:     Config{URL: "ftp://example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPI_SigningHost(t *testing.T) {
+	hosts := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"message": "hello"}}`))
+	}))
+	defer server.Close()
+
+	host := "example.appsync-api.us-east-1.amazonaws.com"
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "api key", cfg: Config{URL: server.URL, SigningHost: host, APIKey: "da2-xxx"}},
+		{name: "token", cfg: Config{URL: server.URL, SigningHost: host, Token: "Bearer xxx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer api.Close()
+			if _, err := api.Query(graphql.PostRequest{Query: "query Message() { message }"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := <-hosts; got != host {
+				t.Errorf("want: %s, got: %s", host, got)
+			}
+			if got := api.header.Get("host"); got != host {
+				t.Errorf("realtime host: %s", got)
+			}
+		})
+	}
+}
+
+func TestAPI_QueryMutate(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	api, err := New(Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	query := graphql.PostRequest{Query: "query Message() { message }"}
+	variables := json.RawMessage(`{ "message": "Hi, AppSync!" }`)
+	mutation := graphql.PostRequest{Query: "mutation Echo($message: String!) { echo(message: $message) }", Variables: &variables}
+
+	if _, err := api.Query(mutation); err == nil {
+		t.Error("Query() accepted a mutation")
+	}
+	if _, err := api.Mutate(query); err == nil {
+		t.Error("Mutate() accepted a query")
+	}
+
+	response, err := api.Mutate(mutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := new(string)
+	if err := response.DataAs(echo); err != nil {
+		t.Fatal(err)
+	}
+	if *echo != "Hi, AppSync!" {
+		t.Errorf("got: %s", *echo)
+	}
+
+	response, err = api.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := new(string)
+	if err := response.DataAs(message); err != nil {
+		t.Fatal(err)
+	}
+	if *message != "Hi, AppSync!" {
+		t.Errorf("got: %s", *message)
+	}
+}
+
+func TestAPI_Subscribe(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	api, err := New(Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	subscription := graphql.PostRequest{Query: "subscription SubscribeToEcho() { subscribeToEcho }"}
+	if _, err := api.Subscribe(graphql.PostRequest{Query: "query Message() { message }"}, nil); err == nil {
+		t.Error("Subscribe() accepted a query")
+	}
+
+	ch1 := make(chan *graphql.Response, 1)
+	stop1, err := api.Subscribe(subscription, func(r *graphql.Response) { ch1 <- r })
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch2 := make(chan *graphql.Response, 1)
+	stop2, err := api.Subscribe(subscription, func(r *graphql.Response) { ch2 <- r })
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := api.conn
+
+	variables := json.RawMessage(`{ "message": "Hi, AppSync!" }`)
+	if _, err := api.Mutate(graphql.PostRequest{
+		Query:     "mutation Echo($message: String!) { echo(message: $message) }",
+		Variables: &variables,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []chan *graphql.Response{ch1, ch2} {
+		select {
+		case r := <-ch:
+			data := new(string)
+			if err := r.DataAs(data); err != nil {
+				t.Fatal(err)
+			}
+			if *data != "Hi, AppSync!" {
+				t.Errorf("got: %s", *data)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	stop1()
+	stop1()
+	if !conn.alive() || conn.len() != 1 {
+		t.Errorf("the connection should stay open for the remaining subscription")
+	}
+	stop2()
+	select {
+	case <-conn.done:
+	case <-time.After(5 * time.Second):
+		t.Error("the connection should be closed after the last subscription")
+	}
+	if api.conn != nil {
+		t.Error("the connection should be released")
+	}
+}
+
+func TestAPI_SubscribeStopFromCallback(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	api, err := New(Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	stopped := make(chan struct{})
+	var stop func()
+	var once sync.Once
+	ready := make(chan struct{})
+	stop, err = api.Subscribe(graphql.PostRequest{Query: "subscription SubscribeToEcho() { subscribeToEcho }"},
+		func(r *graphql.Response) {
+			<-ready
+			once.Do(func() {
+				stop()
+				close(stopped)
+			})
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(ready)
+
+	variables := json.RawMessage(`{ "message": "Hi, AppSync!" }`)
+	if _, err := api.Mutate(graphql.PostRequest{
+		Query:     "mutation Echo($message: String!) { echo(message: $message) }",
+		Variables: &variables,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopping the subscription from its callback deadlocked")
+	}
+}
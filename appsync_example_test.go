@@ -1,7 +1,6 @@
 package appsync_test
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -44,11 +43,8 @@ func ExampleClient_Post_mutation() {
 
 	client := appsync.NewClient(appsync.NewGraphQLClient(graphql.NewClient(server.URL)))
 	mutation := `mutation Echo($message: String!) { echo(message: $message) }`
-	variables := json.RawMessage(fmt.Sprintf(`{ "message": "%s"	}`, "Hi, AppSync!"))
-	response, err := client.Post(graphql.PostRequest{
-		Query:     mutation,
-		Variables: &variables,
-	})
+	request := graphql.NewRequest(mutation).Var("message", "Hi, AppSync!").MustBuild()
+	response, err := client.Post(request)
 	if err != nil {
 		slog.Error("unable to post mutation", "error", err)
 		os.Exit(1)
@@ -86,12 +82,16 @@ func ExampleClient_mqtt_subscription() {
 	}
 
 	ch := make(chan *graphql.Response)
-	subscriber := appsync.NewSubscriber(*ext,
+	subscriber, err := appsync.NewSubscriber(*ext,
 		func(r *graphql.Response) { ch <- r },
 		func(err error) {
-			slog.Warn("unable to create new subscriber", "error", err)
+			slog.Warn("connection lost", "error", err)
 		},
 	)
+	if err != nil {
+		slog.Error("unable to create new subscriber", "error", err)
+		os.Exit(1)
+	}
 
 	if err := subscriber.Start(); err != nil {
 		slog.Error("unable to start subscriber", "error", err)
@@ -100,11 +100,8 @@ func ExampleClient_mqtt_subscription() {
 	defer subscriber.Stop()
 
 	mutation := `mutation Echo($message: String!) { echo(message: $message) }`
-	variables := json.RawMessage(fmt.Sprintf(`{ "message": "%s" }`, "Hi, AppSync!"))
-	_, err = client.Post(graphql.PostRequest{
-		Query:     mutation,
-		Variables: &variables,
-	})
+	request := graphql.NewRequest(mutation).Var("message", "Hi, AppSync!").MustBuild()
+	_, err = client.Post(request)
 	if err != nil {
 		slog.Error("unable to post mutation", "error", err)
 		os.Exit(1)
@@ -148,11 +145,8 @@ func ExampleClient_graphqlws_subscription() {
 	defer subscriber.Stop()
 
 	mutation := `mutation Echo($message: String!) { echo(message: $message) }`
-	variables := json.RawMessage(fmt.Sprintf(`{ "message": "%s" }`, "Hi, AppSync!"))
-	_, err := client.Post(graphql.PostRequest{
-		Query:     mutation,
-		Variables: &variables,
-	})
+	request := graphql.NewRequest(mutation).Var("message", "Hi, AppSync!").MustBuild()
+	_, err := client.Post(request)
 	if err != nil {
 		slog.Error("unable to post mutation", "error", err)
 		os.Exit(1)
@@ -169,3 +163,44 @@ func ExampleClient_graphqlws_subscription() {
 	// Output:
 	// Hi, AppSync!
 }
+
+func ExampleAPI_Subscribe() {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	api, err := appsync.New(appsync.Config{URL: server.URL})
+	if err != nil {
+		slog.Error("unable to create api", "error", err)
+		os.Exit(1)
+	}
+	defer api.Close()
+
+	ch := make(chan *graphql.Response)
+	stop, err := api.Subscribe(graphql.PostRequest{
+		Query: `subscription SubscribeToEcho() { subscribeToEcho }`,
+	}, func(r *graphql.Response) { ch <- r })
+	if err != nil {
+		slog.Error("unable to subscribe", "error", err)
+		os.Exit(1)
+	}
+	defer stop()
+
+	mutation := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).
+		Var("message", "Hi, AppSync!").
+		MustBuild()
+	if _, err := api.Mutate(mutation); err != nil {
+		slog.Error("unable to post mutation", "error", err)
+		os.Exit(1)
+	}
+
+	response := <-ch
+	data := new(string)
+	if err := response.DataAs(data); err != nil {
+		slog.Error("unable to process data", "error", err, "response", response)
+		os.Exit(1)
+	}
+	fmt.Println(*data)
+
+	// Output:
+	// Hi, AppSync!
+}
@@ -0,0 +1,388 @@
+package appsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// FetchPolicy determines how a CachedGraphQLClient serves queries.
+type FetchPolicy int
+
+const (
+	// CacheFirst serves queries from the cache and only goes to the network on a cache miss.
+	CacheFirst FetchPolicy = iota
+	// NetworkOnly always goes to the network and stores the result in the cache.
+	NetworkOnly
+	// CacheAndNetwork serves queries from the cache when possible and always refreshes them from the network.
+	CacheAndNetwork
+)
+
+const (
+	refKey         = "__ref"
+	queryKeyPrefix = "ROOT_QUERY:"
+)
+
+// CacheEntry is a value held by a CacheStore.
+type CacheEntry struct {
+	Value   interface{}
+	Expires time.Time
+}
+
+func (e CacheEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
+// CacheStore stores normalized entities and query results.
+type CacheStore interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	Delete(key string)
+	Clear()
+}
+
+type memoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCacheStore returns a CacheStore keeping the entries in memory.
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: map[string]CacheEntry{}}
+}
+
+func (m *memoryCacheStore) Get(key string) (CacheEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[key]
+	return e, ok
+}
+
+func (m *memoryCacheStore) Set(key string, entry CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+}
+
+func (m *memoryCacheStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+func (m *memoryCacheStore) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = map[string]CacheEntry{}
+}
+
+// CacheOption represents options for a CachedGraphQLClient.
+type CacheOption func(*CachedGraphQLClient)
+
+// WithFetchPolicy returns a CacheOption configured with the default fetch policy for queries.
+func WithFetchPolicy(policy FetchPolicy) CacheOption {
+	return func(c *CachedGraphQLClient) {
+		c.policy = policy
+	}
+}
+
+// WithCacheTTL returns a CacheOption configured with the time to live of cached entities and query results.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CachedGraphQLClient) {
+		c.ttl = ttl
+	}
+}
+
+// WithCacheStore returns a CacheOption configured with the given CacheStore.
+func WithCacheStore(store CacheStore) CacheOption {
+	return func(c *CachedGraphQLClient) {
+		c.store = store
+	}
+}
+
+// CachedGraphQLClient is a GraphQLClient with a normalized response cache.
+// Objects carrying both __typename and id are stored once as entities and shared by every query result referring to them,
+// so that mutation responses and subscription data passed to Update refresh all cached queries.
+// A cached entity holds the union of the fields fetched for it, so cached results may carry more fields than were selected.
+type CachedGraphQLClient struct {
+	client GraphQLClient
+	store  CacheStore
+	policy FetchPolicy
+	ttl    time.Duration
+
+	mu sync.Mutex
+}
+
+// NewCachedGraphQLClient returns a CachedGraphQLClient instance.
+func NewCachedGraphQLClient(client GraphQLClient, opts ...CacheOption) *CachedGraphQLClient {
+	c := &CachedGraphQLClient{
+		client: client,
+		store:  NewMemoryCacheStore(),
+		policy: CacheFirst,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Post is a synchronous GraphQL POST request using the default fetch policy.
+func (c *CachedGraphQLClient) Post(header http.Header, request graphql.PostRequest) (*graphql.Response, error) {
+	return c.PostWithPolicy(header, request, c.policy)
+}
+
+// PostWithPolicy is a synchronous GraphQL POST request using the given fetch policy.
+// With CacheAndNetwork a cached result is returned immediately and refreshed in the background.
+func (c *CachedGraphQLClient) PostWithPolicy(header http.Header, request graphql.PostRequest, policy FetchPolicy) (*graphql.Response, error) {
+	if !request.IsQuery() || policy == NetworkOnly {
+		return c.post(header, request)
+	}
+	cached, ok := c.read(request)
+	if !ok {
+		return c.post(header, request)
+	}
+	if policy == CacheAndNetwork {
+		go func() {
+			if _, err := c.post(header, request); err != nil {
+				slog.Warn("unable to refresh cached query", "error", err, "request", request)
+			}
+		}()
+	}
+	return cached, nil
+}
+
+// PostAsync is an asynchronous GraphQL POST request using the default fetch policy.
+// With CacheAndNetwork the callback is called with the cached result, if any, and again with the network result.
+func (c *CachedGraphQLClient) PostAsync(header http.Header, request graphql.PostRequest, callback func(*graphql.Response, error)) (context.CancelFunc, error) {
+	if request.IsQuery() && c.policy != NetworkOnly {
+		if cached, ok := c.read(request); ok {
+			if c.policy == CacheFirst {
+				go callback(cached, nil)
+				return func() {}, nil
+			}
+			callback(cached, nil)
+		}
+	}
+	return c.client.PostAsync(header, request, func(r *graphql.Response, err error) {
+		if err == nil {
+			c.write(request, r)
+		}
+		callback(r, err)
+	})
+}
+
+// Update stores the entities found in the response, e.g. subscription data.
+func (c *CachedGraphQLClient) Update(response *graphql.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if response != nil {
+		c.normalize(response.Data, time.Now())
+	}
+}
+
+// Evict removes the entity with the given __typename and id.
+// Cached query results referring to it become cache misses.
+func (c *CachedGraphQLClient) Evict(typename, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.Delete(entityKey(typename, id))
+}
+
+// EvictQuery removes the cached result of the request.
+func (c *CachedGraphQLClient) EvictQuery(request graphql.PostRequest) {
+	key, err := queryKey(request)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.Delete(key)
+}
+
+// Reset removes every entity and query result.
+func (c *CachedGraphQLClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.Clear()
+}
+
+func (c *CachedGraphQLClient) post(header http.Header, request graphql.PostRequest) (*graphql.Response, error) {
+	response, err := c.client.Post(header, request)
+	if err != nil {
+		return nil, err
+	}
+	c.write(request, response)
+	return response, nil
+}
+
+func (c *CachedGraphQLClient) write(request graphql.PostRequest, response *graphql.Response) {
+	if response == nil || (response.Errors != nil && len(*response.Errors) != 0) {
+		return
+	}
+	if response.StatusCode != nil && *response.StatusCode != http.StatusOK {
+		return
+	}
+	if request.IsSubscription() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	normalized := c.normalize(response.Data, now)
+	if !request.IsQuery() {
+		return
+	}
+	key, err := queryKey(request)
+	if err != nil {
+		slog.Warn("unable to compute cache key", "error", err, "request", request)
+		return
+	}
+	c.store.Set(key, c.entry(normalized, now))
+}
+
+func (c *CachedGraphQLClient) read(request graphql.PostRequest) (*graphql.Response, bool) {
+	key, err := queryKey(request)
+	if err != nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	e, ok := c.store.Get(key)
+	if !ok || e.expired(now) {
+		return nil, false
+	}
+	data, ok := c.denormalize(e.Value, now, map[string]bool{})
+	if !ok {
+		return nil, false
+	}
+	statusOK := http.StatusOK
+	return &graphql.Response{StatusCode: &statusOK, Data: data}, true
+}
+
+func (c *CachedGraphQLClient) entry(value interface{}, now time.Time) CacheEntry {
+	e := CacheEntry{Value: value}
+	if c.ttl > 0 {
+		e.Expires = now.Add(c.ttl)
+	}
+	return e
+}
+
+// normalize stores the entities in v and returns v with the entities replaced by references.
+func (c *CachedGraphQLClient) normalize(v interface{}, now time.Time) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = c.normalize(v, now)
+		}
+		key, ok := entityKeyOf(t)
+		if !ok {
+			return m
+		}
+		if e, ok := c.store.Get(key); ok && !e.expired(now) {
+			if old, ok := e.Value.(map[string]interface{}); ok {
+				for k, v := range m {
+					old[k] = v
+				}
+				m = old
+			}
+		}
+		c.store.Set(key, c.entry(m, now))
+		return map[string]interface{}{refKey: key}
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			a[i] = c.normalize(v, now)
+		}
+		return a
+	}
+	return v
+}
+
+// denormalize resolves the references in v. It fails if a referred entity is missing or expired.
+// An entity referring back to one of its ancestors is resolved to its scalar fields only.
+func (c *CachedGraphQLClient) denormalize(v interface{}, now time.Time, ancestors map[string]bool) (interface{}, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if key, ok := t[refKey].(string); ok && len(t) == 1 {
+			e, ok := c.store.Get(key)
+			if !ok || e.expired(now) {
+				return nil, false
+			}
+			if ancestors[key] {
+				return scalarFields(e.Value), true
+			}
+			ancestors[key] = true
+			defer delete(ancestors, key)
+			return c.denormalize(e.Value, now, ancestors)
+		}
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			d, ok := c.denormalize(v, now, ancestors)
+			if !ok {
+				return nil, false
+			}
+			m[k] = d
+		}
+		return m, true
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			d, ok := c.denormalize(v, now, ancestors)
+			if !ok {
+				return nil, false
+			}
+			a[i] = d
+		}
+		return a, true
+	}
+	return v, true
+}
+
+func scalarFields(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	entity, _ := v.(map[string]interface{})
+	for k, v := range entity {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func entityKey(typename, id string) string {
+	return typename + ":" + id
+}
+
+func entityKeyOf(m map[string]interface{}) (string, bool) {
+	typename, ok := m["__typename"].(string)
+	if !ok {
+		return "", false
+	}
+	switch id := m["id"].(type) {
+	case string:
+		return entityKey(typename, id), true
+	case float64:
+		return entityKey(typename, fmt.Sprint(id)), true
+	}
+	return "", false
+}
+
+func queryKey(request graphql.PostRequest) (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	return queryKeyPrefix + string(b), nil
+}
@@ -0,0 +1,140 @@
+package appsync
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func post(id, title string) map[string]interface{} {
+	return map[string]interface{}{"__typename": "Post", "id": id, "title": title}
+}
+
+func TestCachedGraphQLClient_CacheFirst(t *testing.T) {
+	api := &scriptedGraphQLAPI{}
+	api.push(&graphql.Response{Data: map[string]interface{}{"getPost": post("1", "first")}}, nil)
+	api.push(&graphql.Response{Data: map[string]interface{}{"updatePost": post("1", "updated")}}, nil)
+
+	c := NewCachedGraphQLClient(api)
+	query := graphql.PostRequest{Query: "query { getPost(id: 1) { id title } }"}
+	for i := 0; i < 2; i++ {
+		res, err := c.Post(http.Header{}, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res.Data, map[string]interface{}{"getPost": post("1", "first")}) {
+			t.Fatal(res.Data)
+		}
+	}
+	if len(api.requests) != 1 {
+		t.Fatal(api.requests)
+	}
+
+	if _, err := c.Post(http.Header{}, graphql.PostRequest{Query: "mutation "}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Post(http.Header{}, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Data, map[string]interface{}{"getPost": post("1", "updated")}) {
+		t.Fatal(res.Data)
+	}
+
+	c.Update(&graphql.Response{Data: map[string]interface{}{"onUpdatePost": post("1", "subscribed")}})
+	res, err = c.Post(http.Header{}, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Data, map[string]interface{}{"getPost": post("1", "subscribed")}) {
+		t.Fatal(res.Data)
+	}
+	if len(api.requests) != 2 {
+		t.Fatal(api.requests)
+	}
+
+	c.Evict("Post", "1")
+	if _, err := c.Post(http.Header{}, query); err == nil {
+		t.Fatal("evicted entity is served from the cache")
+	}
+}
+
+func TestCachedGraphQLClient_Policies(t *testing.T) {
+	query := graphql.PostRequest{Query: "query { listPosts { id title } }"}
+	list := func(title string) *graphql.Response {
+		return &graphql.Response{Data: map[string]interface{}{"listPosts": []interface{}{post("1", title)}}}
+	}
+
+	api := &scriptedGraphQLAPI{}
+	api.push(list("a"), nil)
+	api.push(list("b"), nil)
+	c := NewCachedGraphQLClient(api, WithFetchPolicy(NetworkOnly))
+	for _, want := range []string{"a", "b"} {
+		res, err := c.Post(http.Header{}, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res.Data, list(want).Data) {
+			t.Fatal(res.Data)
+		}
+	}
+
+	api.push(list("c"), nil)
+	ch := make(chan *graphql.Response, 2)
+	if _, err := c.PostWithPolicy(http.Header{}, query, CacheAndNetwork); err != nil {
+		t.Fatal(err)
+	}
+	c.policy = CacheAndNetwork
+	for i := 0; i < 100 && api.pending() != 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	api.push(list("d"), nil)
+	if _, err := c.PostAsync(http.Header{}, query, func(r *graphql.Response, err error) { ch <- r }); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-ch; !reflect.DeepEqual(r.Data, list("c").Data) {
+		t.Fatal(r.Data)
+	}
+	if r := <-ch; !reflect.DeepEqual(r.Data, list("d").Data) {
+		t.Fatal(r.Data)
+	}
+}
+
+func TestCachedGraphQLClient_TTL(t *testing.T) {
+	api := &scriptedGraphQLAPI{}
+	api.push(&graphql.Response{Data: map[string]interface{}{"getPost": post("1", "first")}}, nil)
+	c := NewCachedGraphQLClient(api, WithCacheTTL(time.Nanosecond))
+	query := graphql.PostRequest{Query: "query { getPost(id: 1) { id title } }"}
+	if _, err := c.Post(http.Header{}, query); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := c.Post(http.Header{}, query); err == nil {
+		t.Fatal("expired query is served from the cache")
+	}
+}
+
+func TestCachedGraphQLClient_Cycle(t *testing.T) {
+	author := map[string]interface{}{"__typename": "Author", "id": "a", "name": "n"}
+	p := post("1", "t")
+	p["author"] = author
+	author["posts"] = []interface{}{post("1", "t")}
+
+	api := &scriptedGraphQLAPI{}
+	api.push(&graphql.Response{Data: map[string]interface{}{"getPost": p}}, nil)
+	c := NewCachedGraphQLClient(api)
+	query := graphql.PostRequest{Query: "query "}
+	if _, err := c.Post(http.Header{}, query); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Post(http.Header{}, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Data, map[string]interface{}{"getPost": p}) {
+		t.Fatal(res.Data)
+	}
+}
@@ -0,0 +1,194 @@
+package appsync
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// CatchUpOption represents options for a CatchUp.
+type CatchUpOption func(*CatchUp)
+
+// WithCatchUpDeduplication returns a CatchUpOption that drops live events already delivered by the catch-up query,
+// and vice versa, using the given key. The most recent capacity keys are remembered.
+func WithCatchUpDeduplication(key func(*graphql.Response) (string, bool), capacity int) CatchUpOption {
+	return func(c *CatchUp) {
+		c.dedup = WithDeduplication(key, capacity)
+	}
+}
+
+// WithCursor returns a CatchUpOption configured with the cursor extractor and the initial cursor.
+// The cursor of the last delivered response is passed to the catch-up query on the next Start.
+func WithCursor(cursor func(*graphql.Response) (string, bool), initial string) CatchUpOption {
+	return func(c *CatchUp) {
+		c.cursorOf = cursor
+		c.cursor = initial
+	}
+}
+
+// CatchUp pairs a subscription with a catch-up query so that nothing is missed across restarts.
+// Live events are buffered while the catch-up query runs and flushed afterwards.
+// Pass CatchUp.Receive as the onReceive callback of a subscriber.
+type CatchUp struct {
+	client    *Client
+	query     func(cursor string, nextToken *string) graphql.PostRequest
+	items     func(*graphql.Response) ([]*graphql.Response, *string, error)
+	onReceive func(*graphql.Response)
+	dedup     DeliveryOption
+	cursorOf  func(*graphql.Response) (string, bool)
+
+	mu        sync.Mutex
+	delivery  *Delivery
+	cursor    string
+	buffering bool
+	buffer    []*graphql.Response
+}
+
+// NewCatchUp returns a CatchUp instance.
+// query builds the catch-up request for a cursor and an optional page token, and items splits its response
+// into responses shaped like the live events together with the next page token.
+func NewCatchUp(client *Client,
+	query func(cursor string, nextToken *string) graphql.PostRequest,
+	items func(*graphql.Response) ([]*graphql.Response, *string, error),
+	onReceive func(*graphql.Response),
+	opts ...CatchUpOption) *CatchUp {
+	c := &CatchUp{
+		client:    client,
+		query:     query,
+		items:     items,
+		onReceive: onReceive,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	var dopts []DeliveryOption
+	if c.dedup != nil {
+		dopts = append(dopts, c.dedup)
+	}
+	c.delivery = NewDelivery(c.deliver, dopts...)
+	return c
+}
+
+// Cursor returns the cursor of the last delivered response.
+func (c *CatchUp) Cursor() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursor
+}
+
+// Receive accepts a live event from a subscriber.
+func (c *CatchUp) Receive(response *graphql.Response) {
+	c.mu.Lock()
+	if c.buffering {
+		c.buffer = append(c.buffer, response)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	c.delivery.Receive(response)
+}
+
+// Start calls start to start the subscription, runs the catch-up query from the last seen cursor
+// and then flushes the live events received in the meantime.
+// Buffered live events are flushed even if the catch-up query fails.
+func (c *CatchUp) Start(start func() error) error {
+	c.mu.Lock()
+	c.buffering = true
+	cursor := c.cursor
+	c.mu.Unlock()
+
+	if err := start(); err != nil {
+		slog.Error("unable to start subscription", "error", err)
+		c.mu.Lock()
+		c.buffering = false
+		c.buffer = nil
+		c.mu.Unlock()
+		return err
+	}
+
+	err := c.catchUp(cursor)
+	if err != nil {
+		slog.Error("unable to catch up", "error", err, "cursor", cursor)
+	}
+
+	// Live events received while flushing are buffered too, until the buffer is empty.
+	c.mu.Lock()
+	for len(c.buffer) > 0 {
+		buffer := c.buffer
+		c.buffer = nil
+		c.mu.Unlock()
+		for _, r := range buffer {
+			c.delivery.Receive(r)
+		}
+		c.mu.Lock()
+	}
+	c.buffering = false
+	c.mu.Unlock()
+	return err
+}
+
+func (c *CatchUp) catchUp(cursor string) error {
+	var nextToken *string
+	for {
+		response, err := c.client.Post(c.query(cursor, nextToken))
+		if err != nil {
+			return err
+		}
+		if response.Errors != nil && len(*response.Errors) > 0 {
+			return fmt.Errorf("catch-up query failed: %v", *response.Errors)
+		}
+		items, next, err := c.items(response)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			c.delivery.Receive(item)
+		}
+		if next == nil || len(*next) == 0 {
+			return nil
+		}
+		nextToken = next
+	}
+}
+
+// deliver is called by the delivery without c.mu held, so that onReceive may call back into c.
+func (c *CatchUp) deliver(response *graphql.Response) {
+	if c.cursorOf != nil && response != nil {
+		if cursor, ok := c.cursorOf(response); ok {
+			c.mu.Lock()
+			c.cursor = cursor
+			c.mu.Unlock()
+		}
+	}
+	c.onReceive(response)
+}
+
+// SyncItems returns an items splitter for catch-up queries shaped like AppSync list or sync queries,
+// i.e. a single top-level field holding "items" and "nextToken".
+// Each item is wrapped under field so that it has the same shape as the live events.
+func SyncItems(field string) func(*graphql.Response) ([]*graphql.Response, *string, error) {
+	return func(r *graphql.Response) ([]*graphql.Response, *string, error) {
+		m, ok := r.Data.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return nil, nil, fmt.Errorf("data is invalid")
+		}
+		var list map[string]interface{}
+		for _, v := range m {
+			if list, ok = v.(map[string]interface{}); !ok {
+				return nil, nil, fmt.Errorf("data is invalid")
+			}
+		}
+		raw, _ := list["items"].([]interface{})
+		items := make([]*graphql.Response, 0, len(raw))
+		for _, item := range raw {
+			items = append(items, &graphql.Response{Data: map[string]interface{}{field: item}})
+		}
+		var next *string
+		if s, ok := list["nextToken"].(string); ok {
+			next = &s
+		}
+		return items, next, nil
+	}
+}
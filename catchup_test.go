@@ -0,0 +1,159 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+type pagedGraphQLAPI struct {
+	pages    []*graphql.Response
+	requests []graphql.PostRequest
+	onPost   func()
+}
+
+func (p *pagedGraphQLAPI) Post(header http.Header, request graphql.PostRequest) (*graphql.Response, error) {
+	p.requests = append(p.requests, request)
+	if p.onPost != nil {
+		p.onPost()
+	}
+	if len(p.pages) == 0 {
+		return nil, errors.New("no more pages")
+	}
+	r := p.pages[0]
+	p.pages = p.pages[1:]
+	return r, nil
+}
+
+func (p *pagedGraphQLAPI) PostAsync(header http.Header, request graphql.PostRequest, callback func(*graphql.Response, error)) (context.CancelFunc, error) {
+	go callback(p.Post(header, request))
+	return func() {}, nil
+}
+
+func syncPage(nextToken interface{}, ids ...string) *graphql.Response {
+	items := []interface{}{}
+	for _, id := range ids {
+		items = append(items, map[string]interface{}{"id": id})
+	}
+	return &graphql.Response{Data: map[string]interface{}{
+		"syncEvents": map[string]interface{}{"items": items, "nextToken": nextToken},
+	}}
+}
+
+func liveEvent(id string) *graphql.Response {
+	return &graphql.Response{Data: map[string]interface{}{
+		"onEvent": map[string]interface{}{"id": id},
+	}}
+}
+
+func TestCatchUp_Start(t *testing.T) {
+	var c *CatchUp
+	api := &pagedGraphQLAPI{
+		pages: []*graphql.Response{syncPage("next", "1", "2"), syncPage(nil, "3")},
+	}
+	api.onPost = func() {
+		// live events arriving while the catch-up query runs
+		c.Receive(liveEvent("3"))
+		c.Receive(liveEvent("4"))
+	}
+
+	var got []string
+	key := FieldKey("onEvent", "id")
+	c = NewCatchUp(NewClient(api),
+		func(cursor string, nextToken *string) graphql.PostRequest {
+			return graphql.PostRequest{Query: "query "}
+		},
+		SyncItems("onEvent"),
+		func(r *graphql.Response) {
+			k, _ := key(r)
+			got = append(got, k)
+		},
+		WithCatchUpDeduplication(key, 100),
+		WithCursor(key, "0"),
+	)
+
+	started := false
+	if err := c.Start(func() error { started = true; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !started {
+		t.Fatal("subscription was not started")
+	}
+	if len(api.requests) != 2 {
+		t.Fatal(api.requests)
+	}
+	want := []string{"1", "2", "3", "4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+	if c.Cursor() != "4" {
+		t.Fatal(c.Cursor())
+	}
+
+	c.Receive(liveEvent("5"))
+	if got[len(got)-1] != "5" {
+		t.Fatal(got)
+	}
+}
+
+func TestCatchUp_StartFailure(t *testing.T) {
+	var got []*graphql.Response
+	c := NewCatchUp(NewClient(&pagedGraphQLAPI{}),
+		func(cursor string, nextToken *string) graphql.PostRequest { return graphql.PostRequest{} },
+		SyncItems("onEvent"),
+		func(r *graphql.Response) { got = append(got, r) },
+	)
+
+	if err := c.Start(func() error {
+		c.Receive(liveEvent("1"))
+		return nil
+	}); err == nil {
+		t.Fatal("catch-up error is not returned")
+	}
+	if len(got) != 1 {
+		t.Fatal("buffered live events are not flushed", got)
+	}
+
+	want := errors.New("start failed")
+	if err := c.Start(func() error { return want }); err != want {
+		t.Fatal(err)
+	}
+}
+
+func TestCatchUp_Reentrant(t *testing.T) {
+	var c *CatchUp
+	var cursors []string
+	key := FieldKey("onEvent", "id")
+	c = NewCatchUp(NewClient(&pagedGraphQLAPI{pages: []*graphql.Response{syncPage(nil, "1")}}),
+		func(cursor string, nextToken *string) graphql.PostRequest {
+			return graphql.PostRequest{Query: "query "}
+		},
+		SyncItems("onEvent"),
+		func(r *graphql.Response) {
+			cursors = append(cursors, c.Cursor())
+			if k, _ := key(r); k == "1" {
+				c.Receive(liveEvent("2"))
+			}
+		},
+		WithCursor(key, "0"),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Start(func() error { return nil }) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("calling the catch-up from its handler deadlocked")
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(cursors, want) {
+		t.Errorf("got: %v, want: %v", cursors, want)
+	}
+}
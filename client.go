@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/sony/appsync-client-go/graphql"
@@ -14,7 +15,7 @@ import (
 type Client struct {
 	graphQLAPI   GraphQLClient
 	subscriberID string
-	signer       sigv4
+	signer       RequestSigner
 }
 
 // NewClient returns a Client instance.
@@ -26,10 +27,13 @@ func NewClient(graphql GraphQLClient, opts ...ClientOption) *Client {
 	return c
 }
 
+// subscriptionDelay is how long a subscription request waits before returning.
+var subscriptionDelay = 2 * time.Second
+
 func (c *Client) sleepIfNeeded(request graphql.PostRequest) {
 	if request.IsSubscription() {
 		// Here be dragons.
-		time.Sleep(2 * time.Second)
+		time.Sleep(subscriptionDelay)
 	}
 }
 
@@ -48,7 +52,7 @@ func (c *Client) setupHeaders(request graphql.PostRequest) (http.Header, error)
 		slog.Error("unable to marshal request", "error", err, "request", request)
 		return nil, err
 	}
-	h, err := c.signer.signHTTP(jsonBytes)
+	h, err := c.signer.SignHTTP(context.Background(), jsonBytes)
 	if err != nil {
 		slog.Error("unable to sign request", "error", err, "request", request)
 		return nil, err
@@ -61,7 +65,23 @@ func (c *Client) setupHeaders(request graphql.PostRequest) (http.Header, error)
 	return header, nil
 }
 
+// correctClockSkew corrects the clock of the signer by the AppSync time if the response is an expired signature,
+// and reports whether it did. Only signers implementing ClockSkewCorrector are corrected.
+func (c *Client) correctClockSkew(response *graphql.Response) bool {
+	s, ok := c.signer.(ClockSkewCorrector)
+	if !ok {
+		return false
+	}
+	serverTime, ok := signatureServerTime(response)
+	if !ok {
+		return false
+	}
+	s.CorrectClockSkew(serverTime)
+	return true
+}
+
 // Post is a synchronous AppSync GraphQL POST request.
+// A request rejected for an expired signature is signed again at the AppSync time and sent once more.
 func (c *Client) Post(request graphql.PostRequest) (*graphql.Response, error) {
 	defer c.sleepIfNeeded(request)
 	header, err := c.setupHeaders(request)
@@ -69,19 +89,80 @@ func (c *Client) Post(request graphql.PostRequest) (*graphql.Response, error) {
 		slog.Error("unable to setup headers", "error", err, "request", request)
 		return nil, err
 	}
+	response, err := c.graphQLAPI.Post(header, request)
+	if err != nil || !c.correctClockSkew(response) {
+		return response, err
+	}
+	// The signature expired due to clock skew, so it is signed again at the corrected time.
+	header, err = c.setupHeaders(request)
+	if err != nil {
+		slog.Error("unable to setup headers", "error", err, "request", request)
+		return nil, err
+	}
 	return c.graphQLAPI.Post(header, request)
 }
 
 // PostAsync is an asynchronous AppSync GraphQL POST request.
+// Like Post, a request rejected for an expired signature is signed again at the AppSync time and sent once more,
+// and the returned CancelFunc cancels either request.
 func (c *Client) PostAsync(request graphql.PostRequest, callback func(*graphql.Response, error)) (context.CancelFunc, error) {
 	header, err := c.setupHeaders(request)
 	if err != nil {
 		slog.Error("unable to setup headers", "error", err, "request", request)
 		return nil, err
 	}
-	cb := func(g *graphql.Response, err error) {
+
+	var (
+		mu       sync.Mutex
+		cancel   context.CancelFunc
+		canceled bool
+	)
+	done := func(g *graphql.Response, err error) {
 		c.sleepIfNeeded(request)
 		callback(g, err)
 	}
-	return c.graphQLAPI.PostAsync(header, request, cb)
+	retry := func(g *graphql.Response, err error) {
+		if err != nil || !c.correctClockSkew(g) {
+			done(g, err)
+			return
+		}
+		// The signature expired due to clock skew, so it is signed again at the corrected time.
+		header, err := c.setupHeaders(request)
+		if err != nil {
+			slog.Error("unable to setup headers", "error", err, "request", request)
+			done(nil, err)
+			return
+		}
+		mu.Lock()
+		if canceled {
+			mu.Unlock()
+			done(g, nil)
+			return
+		}
+		next, err := c.graphQLAPI.PostAsync(header, request, done)
+		if err == nil {
+			cancel = next
+		}
+		mu.Unlock()
+		if err != nil {
+			done(nil, err)
+		}
+	}
+
+	first, err := c.graphQLAPI.PostAsync(header, request, retry)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	if cancel == nil {
+		cancel = first
+	}
+	mu.Unlock()
+	return func() {
+		mu.Lock()
+		canceled = true
+		current := cancel
+		mu.Unlock()
+		current()
+	}, nil
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// varFlags collects repeated --var name=value flags.
+// Values that are valid JSON are sent as such, anything else is sent as a string.
+type varFlags map[string]json.RawMessage
+
+func (v varFlags) String() string {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (v varFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || len(name) == 0 {
+		return fmt.Errorf("invalid variable %q, want name=value", s)
+	}
+	if json.Valid([]byte(value)) {
+		v[name] = json.RawMessage(value)
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	v[name] = b
+	return nil
+}
+
+type options struct {
+	url           string
+	realtimeURL   string
+	auth          string
+	apiKey        string
+	token         string
+	profile       string
+	region        string
+	output        string
+	file          string
+	query         string
+	operationName string
+	variables     string
+	vars          varFlags
+	timeout       time.Duration
+	verbose       bool
+}
+
+func newFlagSet(name string, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	o.vars = varFlags{}
+	fs.StringVar(&o.url, "url", os.Getenv("APPSYNC_URL"), "AppSync GraphQL API URL (env APPSYNC_URL)")
+	fs.StringVar(&o.realtimeURL, "realtime-url", "", "AppSync realtime URL, derived from --url when empty")
+	fs.StringVar(&o.auth, "auth", "", "authorization mode: api-key, oidc or iam (default: api-key when an API key is set, iam otherwise)")
+	fs.StringVar(&o.apiKey, "api-key", os.Getenv("APPSYNC_API_KEY"), "API key (env APPSYNC_API_KEY)")
+	fs.StringVar(&o.token, "token", os.Getenv("APPSYNC_TOKEN"), "OIDC or Cognito user pools token (env APPSYNC_TOKEN)")
+	fs.StringVar(&o.profile, "profile", "", "AWS shared config profile for iam")
+	fs.StringVar(&o.region, "region", "", "AWS region for iam, taken from the URL or the AWS config when empty")
+	fs.StringVar(&o.output, "output", "json", "output format: json, pretty or data")
+	fs.StringVar(&o.file, "file", "", "file holding the operation, - for stdin")
+	fs.StringVar(&o.query, "query", "", "operation document, read from --file or stdin when empty")
+	fs.StringVar(&o.operationName, "operation-name", "", "operation name")
+	fs.StringVar(&o.variables, "variables", "", "variables as a JSON object, merged with --var")
+	fs.Var(o.vars, "var", "variable as name=value, repeatable")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "request timeout")
+	fs.BoolVar(&o.verbose, "verbose", false, "log debug messages to stderr")
+	return fs
+}
+
+func (o *options) validate() error {
+	if len(o.url) == 0 {
+		return errors.New("--url is required")
+	}
+	if len(o.auth) == 0 {
+		o.auth = "iam"
+		if len(o.apiKey) != 0 {
+			o.auth = "api-key"
+		}
+	}
+	switch o.auth {
+	case "api-key":
+		if len(o.apiKey) == 0 {
+			return errors.New("--api-key is required for api-key authorization")
+		}
+	case "oidc":
+		if len(o.token) == 0 {
+			return errors.New("--token is required for oidc authorization")
+		}
+	case "iam":
+	default:
+		return fmt.Errorf("unknown authorization mode %q", o.auth)
+	}
+	switch o.output {
+	case "json", "pretty", "data":
+	default:
+		return fmt.Errorf("unknown output format %q", o.output)
+	}
+	return nil
+}
+
+// document returns the operation from --query, --file or stdin.
+func (o *options) document(stdin io.Reader) (string, error) {
+	if len(o.query) != 0 {
+		return o.query, nil
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if len(o.file) == 0 || o.file == "-" {
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(o.file)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return "", errors.New("the operation is empty")
+	}
+	return string(b), nil
+}
+
+// variablesJSON merges --variables and --var into a JSON object.
+func (o *options) variablesJSON() (*json.RawMessage, error) {
+	vars := map[string]json.RawMessage{}
+	if len(o.variables) != 0 {
+		if err := json.Unmarshal([]byte(o.variables), &vars); err != nil {
+			return nil, fmt.Errorf("invalid --variables: %w", err)
+		}
+	}
+	for k, v := range o.vars {
+		vars[k] = v
+	}
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(b)
+	return &raw, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestVariablesJSON(t *testing.T) {
+	o := new(options)
+	fs := newFlagSet("query", o)
+	err := fs.Parse([]string{
+		"--variables", `{"limit": 10, "id": "x"}`,
+		"--var", "id=1",
+		"--var", "name=John Doe",
+		"--var", `filter={"done": true}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := o.variablesJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(*raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"limit":  float64(10),
+		"id":     float64(1),
+		"name":   "John Doe",
+		"filter": map[string]interface{}{"done": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	if err := fs.Parse([]string{"--var", "novalue"}); err == nil {
+		t.Fatal("invalid --var is accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		o        options
+		wantAuth string
+		wantErr  bool
+	}{
+		{name: "api key by default", o: options{url: "u", apiKey: "k", output: "json"}, wantAuth: "api-key"},
+		{name: "iam by default", o: options{url: "u", output: "json"}, wantAuth: "iam"},
+		{name: "oidc without token", o: options{url: "u", auth: "oidc", output: "json"}, wantErr: true},
+		{name: "unknown output", o: options{url: "u", apiKey: "k", output: "yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.o.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatal(err)
+			}
+			if !tt.wantErr && tt.o.auth != tt.wantAuth {
+				t.Fatal(tt.o.auth)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Command appsync runs GraphQL queries, mutations and subscriptions against AWS AppSync APIs.
+//
+// Usage:
+//
+//	appsync query     --url URL [flags] < query.graphql
+//	appsync mutate    --url URL [flags] --file mutation.graphql --var id=1
+//	appsync subscribe --url URL [flags] --query 'subscription { onEvent { id } }'
+//
+// Run "appsync <command> -h" for the flags of a command.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	appsync "github.com/sony/appsync-client-go"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+const usage = `usage: appsync <command> [flags]
+
+commands:
+  query      run a query and print the response
+  mutate     run a mutation and print the response
+  subscribe  start a subscription and print each event as a JSON line until interrupted
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command := args[0]
+	switch command {
+	case "query", "mutate", "subscribe":
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", command, usage)
+		return 2
+	}
+
+	o := new(options)
+	fs := newFlagSet(command, o)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if err := o.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	level := slog.LevelWarn
+	if o.verbose {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level})))
+
+	document, err := o.document(stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	variables, err := o.variablesJSON()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	request := graphql.PostRequest{Query: document, Variables: variables}
+	if len(o.operationName) != 0 {
+		request.OperationName = &o.operationName
+	}
+
+	var eopts []appsync.EndpointOption
+	if len(o.region) != 0 {
+		eopts = append(eopts, appsync.WithEndpointRegion(o.region))
+	}
+	if len(o.realtimeURL) != 0 {
+		eopts = append(eopts, appsync.WithRealtimeEndpoint(o.realtimeURL))
+	}
+	endpoint, err := appsync.ResolveEndpoint(o.url, eopts...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	auth, err := newAuth(ctx, o, endpoint)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if command == "subscribe" {
+		err = subscribe(ctx, o, endpoint, auth, request, stdout)
+	} else {
+		err = post(o, auth, request, stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+type auth struct {
+	graphql    []graphql.ClientOption
+	client     []appsync.ClientOption
+	subscriber []appsync.PureWebSocketSubscriberOption
+}
+
+func newAuth(ctx context.Context, o *options, endpoint *appsync.Endpoint) (*auth, error) {
+	switch o.auth {
+	case "api-key":
+		return &auth{
+			graphql:    []graphql.ClientOption{graphql.WithAPIKey(o.apiKey)},
+			subscriber: []appsync.PureWebSocketSubscriberOption{appsync.WithAPIKey(o.url, o.apiKey)},
+		}, nil
+	case "oidc":
+		return &auth{
+			graphql:    []graphql.ClientOption{graphql.WithCredential(o.token)},
+			subscriber: []appsync.PureWebSocketSubscriberOption{appsync.WithOIDC(o.url, o.token)},
+		}, nil
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if len(o.profile) != 0 {
+		opts = append(opts, config.WithSharedConfigProfile(o.profile))
+	}
+	if len(endpoint.Region) != 0 {
+		opts = append(opts, config.WithRegion(endpoint.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS config: %w", err)
+	}
+	if len(cfg.Region) == 0 {
+		return nil, errors.New("--region is required for iam authorization")
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve AWS credentials: %w", err)
+	}
+	signer := v4.NewSigner()
+	return &auth{
+		client:     []appsync.ClientOption{appsync.WithIAMAuthorizationV2(signer, creds, cfg.Region, o.url)},
+		subscriber: []appsync.PureWebSocketSubscriberOption{appsync.WithIAMV2(signer, creds, cfg.Region, o.url)},
+	}, nil
+}
+
+func post(o *options, auth *auth, request graphql.PostRequest, stdout io.Writer) error {
+	opts := append([]graphql.ClientOption{graphql.WithTimeout(o.timeout)}, auth.graphql...)
+	client := appsync.NewClient(appsync.NewGraphQLClient(graphql.NewClient(o.url, opts...)), auth.client...)
+	response, err := client.Post(request)
+	if err != nil {
+		return err
+	}
+	if err := write(stdout, o.output, response); err != nil {
+		return err
+	}
+	if response.Errors != nil && len(*response.Errors) != 0 {
+		return errors.New("the response has errors")
+	}
+	return nil
+}
+
+func subscribe(ctx context.Context, o *options, endpoint *appsync.Endpoint, auth *auth, request graphql.PostRequest, stdout io.Writer) error {
+	if !request.IsSubscription() {
+		return errors.New("the operation is not a subscription")
+	}
+
+	done := make(chan error, 1)
+	finish := func(err error) {
+		select {
+		case done <- err:
+		default:
+		}
+	}
+	s := appsync.NewPureWebSocketSubscriber(endpoint.RealtimeURL, request,
+		func(r *graphql.Response) {
+			if err := write(stdout, o.output, r); err != nil {
+				finish(err)
+				return
+			}
+			if r.Errors != nil && len(*r.Errors) != 0 {
+				finish(errors.New("the subscription failed"))
+			}
+		},
+		func(err error) { finish(fmt.Errorf("connection lost: %w", err)) },
+		auth.subscriber...,
+	)
+	if err := s.Start(); err != nil {
+		return err
+	}
+	defer s.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-done:
+		return err
+	}
+}
+
+func write(w io.Writer, format string, response *graphql.Response) error {
+	var (
+		b   []byte
+		err error
+	)
+	switch format {
+	case "pretty":
+		b, err = json.MarshalIndent(response, "", "  ")
+	case "data":
+		b, err = json.Marshal(response.Data)
+	default:
+		b, err = json.Marshal(response)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func TestRun(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		want     string
+	}{
+		{
+			name:  "query from stdin",
+			args:  []string{"query", "--url", server.URL, "--api-key", "key"},
+			stdin: "query Message() { message }",
+			want:  `{"statusCode":200,"data":{"message":"Hello, AppSync!"},"errors":null,"extensions":null}` + "\n",
+		},
+		{
+			name: "mutate with variables",
+			args: []string{"mutate", "--url", server.URL, "--api-key", "key", "--output", "data",
+				"--query", "mutation Echo($message: String!) { echo(message: $message) }", "--var", "message=Hi"},
+			want: `{"echo":"Hi"}` + "\n",
+		},
+		{
+			name:     "missing url",
+			args:     []string{"query", "--api-key", "key", "--query", "query { message }"},
+			wantCode: 2,
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"delete"},
+			wantCode: 2,
+		},
+		{
+			name:     "subscribe requires a subscription",
+			args:     []string{"subscribe", "--url", server.URL, "--api-key", "key", "--query", "query { message }"},
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APPSYNC_URL", "")
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), stdout, stderr)
+			if code != tt.wantCode {
+				t.Fatalf("code: %d, want: %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			if len(tt.want) != 0 && stdout.String() != tt.want {
+				t.Fatalf("got: %s, want: %s", stdout, tt.want)
+			}
+		})
+	}
+}
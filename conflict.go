@@ -0,0 +1,149 @@
+package appsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+const (
+	versionField       = "_version"
+	maxConflictRetries = 3
+)
+
+var conflictErrorTypes = map[string]bool{
+	"ConflictUnhandled":                        true,
+	"DynamoDB:ConditionalCheckFailedException": true,
+}
+
+// ConflictError represents a mutation rejected by a versioned data source because of a _version mismatch.
+type ConflictError struct {
+	ErrorType string
+	Message   string
+	Path      []interface{}
+	// Item is the server-side item, taken from the error data or errorInfo.
+	Item map[string]interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorType, e.Message)
+}
+
+// Version returns the _version of the server-side item.
+func (e *ConflictError) Version() (int64, bool) {
+	switch v := e.Item[versionField].(type) {
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// NewConflictError returns the first conflict error in the response, if any.
+func NewConflictError(response *graphql.Response) (*ConflictError, bool) {
+	if response == nil || response.Errors == nil {
+		return nil, false
+	}
+	for _, e := range *response.Errors {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		errorType, _ := m["errorType"].(string)
+		if !conflictErrorTypes[errorType] {
+			continue
+		}
+		ce := &ConflictError{ErrorType: errorType}
+		ce.Message, _ = m["message"].(string)
+		ce.Path, _ = m["path"].([]interface{})
+		if ce.Item, ok = m["data"].(map[string]interface{}); !ok {
+			ce.Item, _ = m["errorInfo"].(map[string]interface{})
+		}
+		return ce, true
+	}
+	return nil, false
+}
+
+// ConflictResolver resolves a conflict between the local input of a mutation and the server-side item.
+// It returns the input to retry the mutation with, or nil to accept the server-side item.
+// The _version of the returned input is set to the server-side version before retrying.
+type ConflictResolver func(local, server map[string]interface{}) (map[string]interface{}, error)
+
+// ClientWins is a ConflictResolver retrying the mutation with the local input.
+func ClientWins(local, server map[string]interface{}) (map[string]interface{}, error) {
+	return local, nil
+}
+
+// ServerWins is a ConflictResolver accepting the server-side item.
+func ServerWins(local, server map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+// PostWithConflictResolver is a synchronous AppSync GraphQL POST request for mutations against versioned data sources.
+// When the mutation is rejected because of a conflict, the resolver is applied to the input held by the given variable
+// and the mutation is retried with the server-side _version.
+// When the resolver accepts the server-side item, it is returned as the data of the mutation field.
+func (c *Client) PostWithConflictResolver(request graphql.PostRequest, variable string, resolver ConflictResolver) (*graphql.Response, error) {
+	for i := 0; ; i++ {
+		response, err := c.Post(request)
+		if err != nil {
+			return nil, err
+		}
+		conflict, ok := NewConflictError(response)
+		if !ok {
+			return response, nil
+		}
+		if i == maxConflictRetries {
+			slog.Warn("conflict retries exhausted", "error", conflict)
+			return response, conflict
+		}
+		if conflict.Item == nil {
+			return response, fmt.Errorf("server item is missing: %w", conflict)
+		}
+
+		variables := map[string]interface{}{}
+		if request.Variables != nil {
+			if err := json.Unmarshal(*request.Variables, &variables); err != nil {
+				slog.Error("unable to unmarshal variables", "error", err)
+				return nil, err
+			}
+		}
+		local, ok := variables[variable].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("variable %q is not an input object", variable)
+		}
+		resolved, err := resolver(local, conflict.Item)
+		if err != nil {
+			slog.Error("unable to resolve conflict", "error", err)
+			return nil, err
+		}
+		if resolved == nil {
+			return serverWinsResponse(response, conflict), nil
+		}
+
+		resolved[versionField] = conflict.Item[versionField]
+		variables[variable] = resolved
+		b, err := json.Marshal(variables)
+		if err != nil {
+			slog.Error("unable to marshal variables", "error", err)
+			return nil, err
+		}
+		raw := json.RawMessage(b)
+		request.Variables = &raw
+		slog.Debug("retrying mutation after conflict", "version", conflict.Item[versionField])
+	}
+}
+
+func serverWinsResponse(response *graphql.Response, conflict *ConflictError) *graphql.Response {
+	var data interface{} = conflict.Item
+	if len(conflict.Path) != 0 {
+		if field, ok := conflict.Path[0].(string); ok {
+			data = map[string]interface{}{field: conflict.Item}
+		}
+	}
+	return &graphql.Response{StatusCode: response.StatusCode, Data: data}
+}
@@ -0,0 +1,118 @@
+package appsync
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func conflictResponse(version float64) *graphql.Response {
+	errs := []interface{}{
+		map[string]interface{}{
+			"path":      []interface{}{"updatePost"},
+			"errorType": "ConflictUnhandled",
+			"message":   "Conflict resolver rejects mutation.",
+			"data":      map[string]interface{}{"id": "1", "title": "server", "_version": version},
+		},
+	}
+	return &graphql.Response{Data: map[string]interface{}{"updatePost": nil}, Errors: &errs}
+}
+
+func TestNewConflictError(t *testing.T) {
+	if _, ok := NewConflictError(&graphql.Response{}); ok {
+		t.Fatal("conflict detected in an empty response")
+	}
+	errs := []interface{}{"error", map[string]interface{}{"errorType": "Unauthorized"}}
+	if _, ok := NewConflictError(&graphql.Response{Errors: &errs}); ok {
+		t.Fatal("conflict detected in an unrelated error")
+	}
+
+	ce, ok := NewConflictError(conflictResponse(3))
+	if !ok {
+		t.Fatal("conflict is not detected")
+	}
+	if ce.ErrorType != "ConflictUnhandled" || ce.Item["title"] != "server" {
+		t.Fatal(ce)
+	}
+	if v, ok := ce.Version(); !ok || v != 3 {
+		t.Fatal(v, ok)
+	}
+}
+
+func TestPostWithConflictResolver(t *testing.T) {
+	variables := json.RawMessage(`{"input": {"id": "1", "title": "local", "_version": 1}}`)
+	request := graphql.PostRequest{Query: "mutation ", Variables: &variables}
+	merge := func(local, server map[string]interface{}) (map[string]interface{}, error) {
+		local["title"] = server["title"].(string) + "+" + local["title"].(string)
+		return local, nil
+	}
+
+	tests := []struct {
+		name      string
+		resolver  ConflictResolver
+		wantInput map[string]interface{}
+		wantData  interface{}
+	}{
+		{
+			name:      "client wins",
+			resolver:  ClientWins,
+			wantInput: map[string]interface{}{"id": "1", "title": "local", "_version": float64(3)},
+			wantData:  "updated",
+		},
+		{
+			name:      "custom merge",
+			resolver:  merge,
+			wantInput: map[string]interface{}{"id": "1", "title": "server+local", "_version": float64(3)},
+			wantData:  "updated",
+		},
+		{
+			name:     "server wins",
+			resolver: ServerWins,
+			wantData: map[string]interface{}{
+				"updatePost": map[string]interface{}{"id": "1", "title": "server", "_version": float64(3)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &scriptedGraphQLAPI{}
+			api.push(conflictResponse(3), nil)
+			api.push(&graphql.Response{Data: "updated"}, nil)
+
+			res, err := NewClient(api).PostWithConflictResolver(request, "input", tt.resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Data, tt.wantData) {
+				t.Fatalf("got: %v, want: %v", res.Data, tt.wantData)
+			}
+			if tt.wantInput == nil {
+				if len(api.requests) != 1 {
+					t.Fatal(api.requests)
+				}
+				return
+			}
+			got := map[string]map[string]interface{}{}
+			if err := json.Unmarshal(*api.requests[1].Variables, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got["input"], tt.wantInput) {
+				t.Fatalf("got: %v, want: %v", got["input"], tt.wantInput)
+			}
+		})
+	}
+}
+
+func TestPostWithConflictResolver_RetriesExhausted(t *testing.T) {
+	api := &scriptedGraphQLAPI{}
+	for i := 0; i <= maxConflictRetries; i++ {
+		api.push(conflictResponse(float64(i)), nil)
+	}
+	variables := json.RawMessage(`{"input": {"id": "1"}}`)
+	_, err := NewClient(api).PostWithConflictResolver(graphql.PostRequest{Query: "mutation ", Variables: &variables}, "input", ClientWins)
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatal(err)
+	}
+}
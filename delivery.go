@@ -0,0 +1,317 @@
+package appsync
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// Gap represents a range of sequence numbers that were never delivered by a subscription.
+type Gap struct {
+	From int64
+	To   int64
+}
+
+// DeliveryOption represents options for a Delivery.
+type DeliveryOption func(*Delivery)
+
+// WithDeduplication returns a DeliveryOption that drops responses whose key has already been seen.
+// The most recent capacity keys are remembered; a capacity of 0 or less remembers every key, growing without bound.
+func WithDeduplication(key func(*graphql.Response) (string, bool), capacity int) DeliveryOption {
+	return func(d *Delivery) {
+		d.key = key
+		d.capacity = capacity
+		d.seen = make(map[string]struct{}, capacity)
+	}
+}
+
+// WithOrdering returns a DeliveryOption that reorders responses by the given sequence.
+// Up to window responses are held back waiting for a missing sequence; a non-zero maxDelay
+// bounds how long a held response may wait before it is delivered anyway.
+func WithOrdering(sequence func(*graphql.Response) (int64, bool), window int, maxDelay time.Duration) DeliveryOption {
+	return func(d *Delivery) {
+		d.sequence = sequence
+		d.window = window
+		d.maxDelay = maxDelay
+	}
+}
+
+// WithGapHandler returns a DeliveryOption configured with the handler called for each detected gap.
+// It is only meaningful together with WithOrdering over a contiguous sequence.
+func WithGapHandler(onGap func(Gap)) DeliveryOption {
+	return func(d *Delivery) {
+		d.onGap = onGap
+	}
+}
+
+// Delivery deduplicates and reorders subscription responses before handing them to a callback.
+// Pass Delivery.Receive as the onReceive callback of a subscriber.
+type Delivery struct {
+	mu        sync.Mutex
+	onReceive func(*graphql.Response)
+
+	key      func(*graphql.Response) (string, bool)
+	capacity int
+	seen     map[string]struct{}
+	fifo     []string
+
+	sequence func(*graphql.Response) (int64, bool)
+	window   int
+	maxDelay time.Duration
+	pending  sequenceHeap
+	next     int64
+	started  bool
+	timer    *time.Timer
+
+	onGap func(Gap)
+
+	// queue holds the callbacks due, which are run outside of mu by the one goroutine delivering.
+	queue      []func()
+	delivering bool
+}
+
+// NewDelivery returns a Delivery instance.
+func NewDelivery(onReceive func(*graphql.Response), opts ...DeliveryOption) *Delivery {
+	d := &Delivery{onReceive: onReceive}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Receive accepts a response from a subscriber.
+func (d *Delivery) Receive(response *graphql.Response) {
+	d.mu.Lock()
+	d.receive(response)
+	d.deliver()
+}
+
+func (d *Delivery) receive(response *graphql.Response) {
+	if response == nil || response.Data == nil {
+		d.enqueueResponse(response)
+		return
+	}
+	if d.isDuplicate(response) {
+		slog.Debug("duplicate response dropped", "response", response)
+		return
+	}
+	if d.sequence == nil {
+		d.enqueueResponse(response)
+		return
+	}
+	seq, ok := d.sequence(response)
+	if !ok {
+		slog.Warn("response has no sequence", "response", response)
+		d.enqueueResponse(response)
+		return
+	}
+	if !d.started {
+		d.started = true
+		d.next = seq
+	}
+	if seq < d.next || d.pending.contains(seq) {
+		slog.Debug("stale response dropped", "sequence", seq, "next", d.next)
+		return
+	}
+	heap.Push(&d.pending, sequenced{seq, response, time.Now()})
+	d.drain(false)
+}
+
+// Flush delivers every held response in order, reporting any gaps between them.
+func (d *Delivery) Flush() {
+	d.mu.Lock()
+	d.drain(true)
+	d.deliver()
+}
+
+// Reset forgets the sequence position and the held responses, e.g. after a resubscription.
+// Deduplication keys are kept.
+func (d *Delivery) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = nil
+	d.started = false
+	d.stopTimer()
+}
+
+func (d *Delivery) enqueueResponse(response *graphql.Response) {
+	d.queue = append(d.queue, func() { d.onReceive(response) })
+}
+
+// deliver runs the queued callbacks in order without holding d.mu, so that they may call back into d.
+// d.mu must be held and is released. Callbacks queued meanwhile are run by the goroutine already delivering.
+func (d *Delivery) deliver() {
+	if d.delivering {
+		d.mu.Unlock()
+		return
+	}
+	d.delivering = true
+	for len(d.queue) > 0 {
+		f := d.queue[0]
+		d.queue = d.queue[1:]
+		d.mu.Unlock()
+		f()
+		d.mu.Lock()
+	}
+	d.delivering = false
+	d.mu.Unlock()
+}
+
+func (d *Delivery) isDuplicate(response *graphql.Response) bool {
+	if d.key == nil {
+		return false
+	}
+	k, ok := d.key(response)
+	if !ok {
+		return false
+	}
+	if _, ok := d.seen[k]; ok {
+		return true
+	}
+	d.seen[k] = struct{}{}
+	d.fifo = append(d.fifo, k)
+	if d.capacity > 0 && len(d.fifo) > d.capacity {
+		delete(d.seen, d.fifo[0])
+		d.fifo = d.fifo[1:]
+	}
+	return false
+}
+
+func (d *Delivery) drain(force bool) {
+	for d.pending.Len() > 0 {
+		head := d.pending[0]
+		switch {
+		case head.seq == d.next:
+		case force, d.pending.Len() > d.window, d.expired(head):
+			if d.onGap != nil {
+				gap := Gap{From: d.next, To: head.seq - 1}
+				d.queue = append(d.queue, func() { d.onGap(gap) })
+			}
+		default:
+			d.resetTimer()
+			return
+		}
+		heap.Pop(&d.pending)
+		d.next = head.seq + 1
+		d.enqueueResponse(head.response)
+	}
+	d.stopTimer()
+}
+
+func (d *Delivery) expired(s sequenced) bool {
+	return d.maxDelay > 0 && time.Since(s.received) >= d.maxDelay
+}
+
+func (d *Delivery) resetTimer() {
+	if d.maxDelay <= 0 {
+		return
+	}
+	wait := d.maxDelay - time.Since(d.pending[0].received)
+	if d.timer == nil {
+		d.timer = time.AfterFunc(wait, func() {
+			d.mu.Lock()
+			d.drain(false)
+			d.deliver()
+		})
+		return
+	}
+	d.timer.Reset(wait)
+}
+
+func (d *Delivery) stopTimer() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+type sequenced struct {
+	seq      int64
+	response *graphql.Response
+	received time.Time
+}
+
+type sequenceHeap []sequenced
+
+func (h sequenceHeap) Len() int           { return len(h) }
+func (h sequenceHeap) Less(i, j int) bool { return h[i].seq < h[j].seq }
+func (h sequenceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *sequenceHeap) Push(x any) {
+	*h = append(*h, x.(sequenced))
+}
+
+func (h *sequenceHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+func (h sequenceHeap) contains(seq int64) bool {
+	for _, s := range h {
+		if s.seq == seq {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldKey returns a key extractor reading the value at the given path in Response.Data.
+func FieldKey(path ...string) func(*graphql.Response) (string, bool) {
+	return func(r *graphql.Response) (string, bool) {
+		v, ok := lookup(r.Data, path)
+		if !ok || v == nil {
+			return "", false
+		}
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		return fmt.Sprint(v), true
+	}
+}
+
+// FieldSequence returns a sequence extractor reading the number at the given path in Response.Data.
+func FieldSequence(path ...string) func(*graphql.Response) (int64, bool) {
+	return func(r *graphql.Response) (int64, bool) {
+		v, ok := lookup(r.Data, path)
+		if !ok {
+			return 0, false
+		}
+		switch n := v.(type) {
+		case float64:
+			return int64(n), true
+		case int64:
+			return n, true
+		case int:
+			return int64(n), true
+		case json.Number:
+			i, err := n.Int64()
+			return i, err == nil
+		case string:
+			i, err := strconv.ParseInt(n, 10, 64)
+			return i, err == nil
+		}
+		return 0, false
+	}
+}
+
+func lookup(data interface{}, path []string) (interface{}, bool) {
+	v := data
+	for _, p := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,165 @@
+package appsync
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func testEvent(id string, seq float64) *graphql.Response {
+	return &graphql.Response{
+		Data: map[string]interface{}{
+			"onEvent": map[string]interface{}{"id": id, "seq": seq},
+		},
+	}
+}
+
+func TestDelivery_Deduplication(t *testing.T) {
+	var got []string
+	d := NewDelivery(func(r *graphql.Response) {
+		k, _ := FieldKey("onEvent", "id")(r)
+		got = append(got, k)
+	}, WithDeduplication(FieldKey("onEvent", "id"), 2))
+
+	for _, id := range []string{"a", "b", "a", "c", "a"} {
+		d.Receive(testEvent(id, 0))
+	}
+	want := []string{"a", "b", "c", "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestDelivery_Ordering(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   int
+		in       []float64
+		flush    bool
+		want     []int64
+		wantGaps []Gap
+	}{
+		{
+			name:   "in order",
+			window: 2,
+			in:     []float64{1, 2, 3},
+			want:   []int64{1, 2, 3},
+		},
+		{
+			name:   "reordered within window",
+			window: 2,
+			in:     []float64{1, 3, 2, 4},
+			want:   []int64{1, 2, 3, 4},
+		},
+		{
+			name:     "gap beyond window",
+			window:   1,
+			in:       []float64{1, 3, 4},
+			want:     []int64{1, 3, 4},
+			wantGaps: []Gap{{2, 2}},
+		},
+		{
+			name:   "stale and duplicate sequences",
+			window: 2,
+			in:     []float64{5, 6, 5, 4},
+			want:   []int64{5, 6},
+		},
+		{
+			name:     "flush",
+			window:   10,
+			in:       []float64{1, 4, 3},
+			flush:    true,
+			want:     []int64{1, 3, 4},
+			wantGaps: []Gap{{2, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			var gaps []Gap
+			seq := FieldSequence("onEvent", "seq")
+			d := NewDelivery(func(r *graphql.Response) {
+				s, _ := seq(r)
+				got = append(got, s)
+			}, WithOrdering(seq, tt.window, 0), WithGapHandler(func(g Gap) { gaps = append(gaps, g) }))
+
+			for _, s := range tt.in {
+				d.Receive(testEvent("", s))
+			}
+			if tt.flush {
+				d.Flush()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gaps, tt.wantGaps) {
+				t.Errorf("gaps: %v, want: %v", gaps, tt.wantGaps)
+			}
+		})
+	}
+}
+
+func TestDelivery_MaxDelay(t *testing.T) {
+	ch := make(chan *graphql.Response, 2)
+	gapCh := make(chan Gap, 1)
+	d := NewDelivery(func(r *graphql.Response) { ch <- r },
+		WithOrdering(FieldSequence("onEvent", "seq"), 10, 10*time.Millisecond),
+		WithGapHandler(func(g Gap) { gapCh <- g }))
+
+	d.Receive(testEvent("", 1))
+	d.Receive(testEvent("", 3))
+	<-ch
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("held response was not delivered")
+	}
+	if g := <-gapCh; g != (Gap{2, 2}) {
+		t.Fatal(g)
+	}
+}
+
+func TestDelivery_PassThroughErrors(t *testing.T) {
+	var got []*graphql.Response
+	d := NewDelivery(func(r *graphql.Response) { got = append(got, r) },
+		WithOrdering(FieldSequence("onEvent", "seq"), 10, 0))
+
+	errs := []interface{}{"error"}
+	d.Receive(&graphql.Response{Errors: &errs})
+	if len(got) != 1 {
+		t.Fatal(got)
+	}
+}
+
+func TestDelivery_Reentrant(t *testing.T) {
+	var got []float64
+	var d *Delivery
+	d = NewDelivery(func(r *graphql.Response) {
+		seq, _ := FieldSequence("onEvent", "seq")(r)
+		got = append(got, float64(seq))
+		if seq == 1 {
+			// Responses received from the callback are delivered after it returns.
+			d.Receive(testEvent("", 2))
+			d.Flush()
+		}
+	}, WithOrdering(FieldSequence("onEvent", "seq"), 10, 10*time.Millisecond),
+		WithGapHandler(func(Gap) { d.Reset() }))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Receive(testEvent("", 1))
+		d.Receive(testEvent("", 4))
+		d.Flush()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("calling the delivery from its callbacks deadlocked")
+	}
+	if want := []float64{1, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package appsync
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var appSyncHost = regexp.MustCompile(`^([a-z0-9]+)\.appsync-api\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// Endpoint represents the endpoints of an AWS AppSync GraphQL API.
+type Endpoint struct {
+	// GraphQLURL is the URL for queries and mutations.
+	GraphQLURL string
+	// RealtimeURL is the URL for pure websocket subscriptions.
+	RealtimeURL string
+	// Host is the host of the GraphQL endpoint, used for authorizing and signing realtime requests.
+	Host string
+	// Region is the region of the API. It is empty for custom domains unless configured.
+	Region string
+	// CustomDomain reports whether the API is served from a custom domain name.
+	CustomDomain bool
+}
+
+// EndpointOption represents options for ResolveEndpoint.
+type EndpointOption func(*Endpoint)
+
+// WithEndpointRegion returns an EndpointOption configured with the region, for custom domains.
+func WithEndpointRegion(region string) EndpointOption {
+	return func(e *Endpoint) {
+		e.Region = region
+	}
+}
+
+// WithRealtimeEndpoint returns an EndpointOption configured with the realtime URL, overriding the derived one.
+func WithRealtimeEndpoint(realtimeURL string) EndpointOption {
+	return func(e *Endpoint) {
+		e.RealtimeURL = realtimeURL
+	}
+}
+
+// ResolveEndpoint returns the Endpoint for the given GraphQL URL.
+//
+// For standard AppSync URLs such as https://xxx.appsync-api.us-east-1.amazonaws.com/graphql the realtime URL is
+// wss://xxx.appsync-realtime-api.us-east-1.amazonaws.com/graphql and the region is parsed from the hostname.
+// Any other hostname is treated as a custom domain name, whose realtime URL is wss://<domain>/graphql/realtime.
+func ResolveEndpoint(graphqlURL string, opts ...EndpointOption) (*Endpoint, error) {
+	u, err := url.Parse(graphqlURL)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "wss"
+	switch u.Scheme {
+	case "https":
+	case "http":
+		scheme = "ws"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q in %s", u.Scheme, graphqlURL)
+	}
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("host is missing in %s", graphqlURL)
+	}
+
+	e := &Endpoint{GraphQLURL: graphqlURL, Host: u.Host}
+	realtime := *u
+	realtime.Scheme = scheme
+	if m := appSyncHost.FindStringSubmatch(u.Hostname()); m != nil {
+		e.Region = m[2]
+		realtime.Host = strings.Replace(u.Host, ".appsync-api.", ".appsync-realtime-api.", 1)
+	} else {
+		e.CustomDomain = true
+		realtime.Path = strings.TrimSuffix(u.Path, "/") + "/realtime"
+	}
+	e.RealtimeURL = realtime.String()
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, nil
+}
@@ -0,0 +1,81 @@
+package appsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		opts    []EndpointOption
+		want    *Endpoint
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			url:  "https://example1234567890000.appsync-api.us-east-1.amazonaws.com/graphql",
+			want: &Endpoint{
+				GraphQLURL:  "https://example1234567890000.appsync-api.us-east-1.amazonaws.com/graphql",
+				RealtimeURL: "wss://example1234567890000.appsync-realtime-api.us-east-1.amazonaws.com/graphql",
+				Host:        "example1234567890000.appsync-api.us-east-1.amazonaws.com",
+				Region:      "us-east-1",
+			},
+		},
+		{
+			name: "china",
+			url:  "https://example.appsync-api.cn-north-1.amazonaws.com.cn/graphql",
+			want: &Endpoint{
+				GraphQLURL:  "https://example.appsync-api.cn-north-1.amazonaws.com.cn/graphql",
+				RealtimeURL: "wss://example.appsync-realtime-api.cn-north-1.amazonaws.com.cn/graphql",
+				Host:        "example.appsync-api.cn-north-1.amazonaws.com.cn",
+				Region:      "cn-north-1",
+			},
+		},
+		{
+			name: "custom domain",
+			url:  "https://api.example.com/graphql",
+			opts: []EndpointOption{WithEndpointRegion("ap-northeast-1")},
+			want: &Endpoint{
+				GraphQLURL:   "https://api.example.com/graphql",
+				RealtimeURL:  "wss://api.example.com/graphql/realtime",
+				Host:         "api.example.com",
+				Region:       "ap-northeast-1",
+				CustomDomain: true,
+			},
+		},
+		{
+			name: "local server",
+			url:  "http://127.0.0.1:8080",
+			opts: []EndpointOption{WithRealtimeEndpoint("ws://127.0.0.1:8080")},
+			want: &Endpoint{
+				GraphQLURL:   "http://127.0.0.1:8080",
+				RealtimeURL:  "ws://127.0.0.1:8080",
+				Host:         "127.0.0.1:8080",
+				CustomDomain: true,
+			},
+		},
+		{
+			name:    "unsupported scheme",
+			url:     "ftp://api.example.com/graphql",
+			wantErr: true,
+		},
+		{
+			name:    "missing host",
+			url:     "/graphql",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveEndpoint(tt.url, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
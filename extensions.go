@@ -2,7 +2,9 @@ package appsync
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/sony/appsync-client-go/graphql"
 )
@@ -22,15 +24,66 @@ type Extensions struct {
 
 // Subscription represents AWS AppSync subscription mqtt topic
 type Subscription struct {
-	Topic      string      `json:"topic"`
-	ExpireTime interface{} `json:"expireTime"`
+	Topic      string     `json:"topic"`
+	ExpireTime ExpireTime `json:"expireTime"`
 }
 
+// ExpireTime is the time the MQTT connection of a subscription expires. It is zero when not provided.
+// Numbers are decoded as epoch seconds, or epoch milliseconds when too large to be seconds,
+// and strings as RFC 3339 timestamps.
+type ExpireTime struct {
+	time.Time
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ExpireTime) MarshalJSON() ([]byte, error) {
+	if e.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(e.Time.Format(time.RFC3339Nano))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *ExpireTime) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case nil:
+		e.Time = time.Time{}
+	case float64:
+		if t > maxEpochSeconds {
+			e.Time = time.UnixMilli(int64(t))
+		} else {
+			e.Time = time.Unix(int64(t), 0)
+		}
+	case string:
+		tm, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return fmt.Errorf("invalid expireTime %q: %w", t, err)
+		}
+		e.Time = tm
+	default:
+		return fmt.Errorf("invalid expireTime %s", string(b))
+	}
+	return nil
+}
+
+// maxEpochSeconds is the largest epoch second treated as seconds, in year 5138.
+const maxEpochSeconds = 1e11
+
+// ErrNoExtensions is returned when a response has no extensions.
+var ErrNoExtensions = errors.New("the response has no extensions")
+
 // NewExtensions returns Extensions instance
 func NewExtensions(response *graphql.Response) (*Extensions, error) {
+	if response == nil || response.Extensions == nil {
+		return nil, ErrNoExtensions
+	}
 	j, ok := (*response.Extensions).(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("Extensions is invalid")
+		return nil, fmt.Errorf("extensions is not an object: %T", *response.Extensions)
 	}
 
 	b, err := json.Marshal(j)
@@ -40,6 +93,10 @@ func NewExtensions(response *graphql.Response) (*Extensions, error) {
 
 	ext := new(Extensions)
 	if err := json.Unmarshal(b, ext); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("extensions field %s is malformed: %w", typeErr.Field, err)
+		}
 		return nil, err
 	}
 	return ext, nil
@@ -0,0 +1,90 @@
+package appsync
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func TestNewExtensions(t *testing.T) {
+	extensions := func(v interface{}) *interface{} { return &v }
+	tests := []struct {
+		name     string
+		response *graphql.Response
+		wantErr  string
+	}{
+		{name: "nil response", wantErr: ErrNoExtensions.Error()},
+		{name: "nil extensions", response: &graphql.Response{}, wantErr: ErrNoExtensions.Error()},
+		{name: "not an object", response: &graphql.Response{Extensions: extensions("ext")}, wantErr: "not an object"},
+		{
+			name: "malformed topics",
+			response: &graphql.Response{Extensions: extensions(map[string]interface{}{
+				"subscription": map[string]interface{}{
+					"mqttConnections": []interface{}{map[string]interface{}{"topics": "echo"}},
+				},
+			})},
+			wantErr: "subscription.mqttConnections.0.topics",
+		},
+		{
+			name: "valid",
+			response: &graphql.Response{Extensions: extensions(map[string]interface{}{
+				"subscription": map[string]interface{}{
+					"mqttConnections":  []interface{}{map[string]interface{}{"topics": []interface{}{"echo"}}},
+					"newSubscriptions": map[string]interface{}{"subscribeToEcho": map[string]interface{}{"topic": "echo"}},
+				},
+			})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext, err := NewExtensions(tt.response)
+			if len(tt.wantErr) == 0 {
+				if err != nil || ext.Subscription.NewSubscriptions["subscribeToEcho"].Topic != "echo" {
+					t.Errorf("got: %+v, %v", ext, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("want: %s, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+	if _, err := NewExtensions(nil); !errors.Is(err, ErrNoExtensions) {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestExpireTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: `null`},
+		{value: `1700000000`, want: time.Unix(1700000000, 0)},
+		{value: `1700000000123`, want: time.UnixMilli(1700000000123)},
+		{value: `"2023-11-14T22:13:20Z"`, want: time.Unix(1700000000, 0)},
+		{value: `"tomorrow"`, wantErr: true},
+		{value: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var e ExpireTime
+			if err := json.Unmarshal([]byte(tt.value), &e); (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !e.Equal(tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, e.Time)
+			}
+		})
+	}
+
+	b, err := json.Marshal(Subscription{Topic: "echo", ExpireTime: ExpireTime{time.Unix(1700000000, 0).UTC()}})
+	if err != nil || string(b) != `{"topic":"echo","expireTime":"2023-11-14T22:13:20Z"}` {
+		t.Errorf("got: %s, %v", b, err)
+	}
+}
@@ -0,0 +1,392 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	namePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+	typePattern = regexp.MustCompile(`^\[*[_A-Za-z][_0-9A-Za-z]*!?(\]!?)*$`)
+)
+
+func checkName(kind, name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+// Value is an argument value of a field.
+type Value interface {
+	writeValue(b *strings.Builder) error
+}
+
+type variableValue string
+
+func (v variableValue) writeValue(b *strings.Builder) error {
+	if err := checkName("variable", string(v)); err != nil {
+		return err
+	}
+	b.WriteString("$" + string(v))
+	return nil
+}
+
+// Variable returns a Value referring to the variable declared with Operation.Var.
+func Variable(name string) Value {
+	return variableValue(name)
+}
+
+type enumValue string
+
+func (v enumValue) writeValue(b *strings.Builder) error {
+	if err := checkName("enum", string(v)); err != nil {
+		return err
+	}
+	b.WriteString(string(v))
+	return nil
+}
+
+// Enum returns a Value of the enum value.
+func Enum(value string) Value {
+	return enumValue(value)
+}
+
+type literalValue struct {
+	v interface{}
+}
+
+func (v literalValue) writeValue(b *strings.Builder) error {
+	raw, err := json.Marshal(v.v)
+	if err != nil {
+		return err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return err
+	}
+	writeLiteral(b, decoded)
+	return nil
+}
+
+func writeLiteral(b *strings.Builder, v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{")
+		for i, k := range keys {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			// Keys of JSON objects from Go values are field names, quote the rest to keep the document valid.
+			if namePattern.MatchString(k) {
+				b.WriteString(k)
+			} else {
+				kb, _ := json.Marshal(k)
+				b.Write(kb)
+			}
+			b.WriteString(": ")
+			writeLiteral(b, t[k])
+		}
+		b.WriteString("}")
+	case []interface{}:
+		b.WriteString("[")
+		for i, e := range t {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			writeLiteral(b, e)
+		}
+		b.WriteString("]")
+	default:
+		// JSON strings, numbers, booleans and null are valid GraphQL literals.
+		raw, _ := json.Marshal(t)
+		b.Write(raw)
+	}
+}
+
+// Literal returns a Value inlining the JSON encoding of v as a GraphQL literal.
+// Prefer variables for values coming from users.
+func Literal(v interface{}) Value {
+	return literalValue{v}
+}
+
+// Selection is a field, fragment spread or inline fragment in a selection set.
+type Selection interface {
+	writeSelection(b *strings.Builder) error
+	fragments() []*FragmentDefinition
+}
+
+func writeSelectionSet(b *strings.Builder, selections []Selection) error {
+	if len(selections) == 0 {
+		return nil
+	}
+	b.WriteString(" {")
+	for _, s := range selections {
+		b.WriteString(" ")
+		if err := s.writeSelection(b); err != nil {
+			return err
+		}
+	}
+	b.WriteString(" }")
+	return nil
+}
+
+func collectFragments(selections []Selection) []*FragmentDefinition {
+	ret := []*FragmentDefinition{}
+	for _, s := range selections {
+		ret = append(ret, s.fragments()...)
+	}
+	return ret
+}
+
+type argument struct {
+	name  string
+	value Value
+}
+
+// FieldSelection is a field in a selection set.
+type FieldSelection struct {
+	name       string
+	alias      string
+	arguments  []argument
+	selections []Selection
+}
+
+// Field returns a FieldSelection of the field.
+func Field(name string) *FieldSelection {
+	return &FieldSelection{name: name}
+}
+
+// Alias sets the alias of the field.
+func (f *FieldSelection) Alias(alias string) *FieldSelection {
+	f.alias = alias
+	return f
+}
+
+// Arg adds an argument to the field.
+func (f *FieldSelection) Arg(name string, value Value) *FieldSelection {
+	f.arguments = append(f.arguments, argument{name, value})
+	return f
+}
+
+// Select adds selections to the selection set of the field.
+func (f *FieldSelection) Select(selections ...Selection) *FieldSelection {
+	f.selections = append(f.selections, selections...)
+	return f
+}
+
+func (f *FieldSelection) writeSelection(b *strings.Builder) error {
+	if len(f.alias) != 0 {
+		if err := checkName("alias", f.alias); err != nil {
+			return err
+		}
+		b.WriteString(f.alias + ": ")
+	}
+	if err := checkName("field", f.name); err != nil {
+		return err
+	}
+	b.WriteString(f.name)
+	if len(f.arguments) != 0 {
+		b.WriteString("(")
+		for i, a := range f.arguments {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			if err := checkName("argument", a.name); err != nil {
+				return err
+			}
+			b.WriteString(a.name + ": ")
+			if err := a.value.writeValue(b); err != nil {
+				return err
+			}
+		}
+		b.WriteString(")")
+	}
+	return writeSelectionSet(b, f.selections)
+}
+
+func (f *FieldSelection) fragments() []*FragmentDefinition {
+	return collectFragments(f.selections)
+}
+
+// FragmentDefinition is a named fragment.
+type FragmentDefinition struct {
+	name       string
+	on         string
+	selections []Selection
+}
+
+// NewFragment returns a FragmentDefinition of the selections on the type.
+func NewFragment(name, on string, selections ...Selection) *FragmentDefinition {
+	return &FragmentDefinition{name, on, selections}
+}
+
+type fragmentSpread struct {
+	fragment *FragmentDefinition
+}
+
+// Spread returns a Selection spreading the fragment. The fragment definition is added to the document.
+func Spread(fragment *FragmentDefinition) Selection {
+	return fragmentSpread{fragment}
+}
+
+func (s fragmentSpread) writeSelection(b *strings.Builder) error {
+	if err := checkName("fragment", s.fragment.name); err != nil {
+		return err
+	}
+	b.WriteString("..." + s.fragment.name)
+	return nil
+}
+
+func (s fragmentSpread) fragments() []*FragmentDefinition {
+	return append([]*FragmentDefinition{s.fragment}, collectFragments(s.fragment.selections)...)
+}
+
+type inlineFragment struct {
+	on         string
+	selections []Selection
+}
+
+// On returns an inline fragment of the selections on the type.
+func On(typeCondition string, selections ...Selection) Selection {
+	return inlineFragment{typeCondition, selections}
+}
+
+func (f inlineFragment) writeSelection(b *strings.Builder) error {
+	if err := checkName("type", f.on); err != nil {
+		return err
+	}
+	b.WriteString("... on " + f.on)
+	return writeSelectionSet(b, f.selections)
+}
+
+func (f inlineFragment) fragments() []*FragmentDefinition {
+	return collectFragments(f.selections)
+}
+
+type variableDefinition struct {
+	name  string
+	typ   string
+	value interface{}
+	bound bool
+}
+
+// Operation builds a query, mutation or subscription.
+type Operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []Selection
+}
+
+// Query returns an Operation building a query. The name may be empty.
+func Query(name string) *Operation {
+	return &Operation{kind: "query", name: name}
+}
+
+// Mutation returns an Operation building a mutation. The name may be empty.
+func Mutation(name string) *Operation {
+	return &Operation{kind: "mutation", name: name}
+}
+
+// Subscription returns an Operation building a subscription. The name may be empty.
+func Subscription(name string) *Operation {
+	return &Operation{kind: "subscription", name: name}
+}
+
+// Var declares the variable of the GraphQL type, such as "ID!" or "[String]", bound to value.
+func (o *Operation) Var(name, typ string, value interface{}) *Operation {
+	o.variables = append(o.variables, variableDefinition{name, typ, value, true})
+	return o
+}
+
+// Declare declares the variable of the GraphQL type without binding a value.
+func (o *Operation) Declare(name, typ string) *Operation {
+	o.variables = append(o.variables, variableDefinition{name: name, typ: typ})
+	return o
+}
+
+// Select adds selections to the selection set of the operation.
+func (o *Operation) Select(selections ...Selection) *Operation {
+	o.selections = append(o.selections, selections...)
+	return o
+}
+
+// Document returns the GraphQL document of the operation and the fragments it uses.
+func (o *Operation) Document() (string, error) {
+	b := new(strings.Builder)
+	b.WriteString(o.kind)
+	if len(o.name) != 0 {
+		if err := checkName("operation", o.name); err != nil {
+			return "", err
+		}
+		b.WriteString(" " + o.name)
+	}
+	if len(o.variables) != 0 {
+		b.WriteString("(")
+		for i, v := range o.variables {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			if err := checkName("variable", v.name); err != nil {
+				return "", err
+			}
+			if !typePattern.MatchString(v.typ) {
+				return "", fmt.Errorf("invalid type %q of variable %s", v.typ, v.name)
+			}
+			b.WriteString("$" + v.name + ": " + v.typ)
+		}
+		b.WriteString(")")
+	}
+	if len(o.selections) == 0 {
+		return "", fmt.Errorf("%s has no selections", o.kind)
+	}
+	if err := writeSelectionSet(b, o.selections); err != nil {
+		return "", err
+	}
+
+	defined := map[string]*FragmentDefinition{}
+	for _, f := range collectFragments(o.selections) {
+		if d, ok := defined[f.name]; ok {
+			if d != f {
+				return "", fmt.Errorf("fragment %s is defined twice", f.name)
+			}
+			continue
+		}
+		defined[f.name] = f
+		if err := checkName("type", f.on); err != nil {
+			return "", err
+		}
+		b.WriteString(" fragment " + f.name + " on " + f.on)
+		if err := writeSelectionSet(b, f.selections); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimPrefix(b.String(), " "), nil
+}
+
+// Build returns the PostRequest of the operation with the bound variables.
+func (o *Operation) Build() (PostRequest, error) {
+	document, err := o.Document()
+	if err != nil {
+		return PostRequest{}, err
+	}
+	b := NewRequest(document)
+	if len(o.name) != 0 {
+		b.OperationName(o.name)
+	}
+	for _, v := range o.variables {
+		if v.bound {
+			b.Var(v.name, v.value)
+		}
+	}
+	return b.Build()
+}
@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"testing"
+)
+
+func TestOperation_Build(t *testing.T) {
+	userFields := NewFragment("UserFields", "User", Field("id"), Field("name"))
+	tests := []struct {
+		name          string
+		operation     *Operation
+		wantQuery     string
+		wantVariables string
+		wantErr       bool
+	}{
+		{
+			name: "mutation with variables",
+			operation: Mutation("Publish").
+				Var("name", "String!", `"; drop`).
+				Var("data", "AWSJSON!", `{"key":"value"}`).
+				Select(Field("publish").
+					Arg("name", Variable("name")).
+					Arg("data", Variable("data")).
+					Select(Field("name"), Field("data"))),
+			wantQuery:     `mutation Publish($name: String!, $data: AWSJSON!) { publish(name: $name, data: $data) { name data } }`,
+			wantVariables: `{"data":"{\"key\":\"value\"}","name":"\"; drop"}`,
+		},
+		{
+			name: "aliases, literals, enums and fragments",
+			operation: Query("").
+				Select(
+					Field("user").Alias("me").Arg("id", Literal("1")).Select(Spread(userFields)),
+					Field("search").
+						Arg("filter", Literal(map[string]interface{}{"tags": []string{"a"}, "min": 1, "not-a-name": true})).
+						Arg("order", Enum("DESC")).
+						Select(Field("__typename"), On("User", Spread(userFields)), On("Post", Field("title"))),
+				),
+			wantQuery: `query { me: user(id: "1") { ...UserFields } search(filter: {min: 1, "not-a-name": true, tags: ["a"]}, order: DESC) ` +
+				`{ __typename ... on User { ...UserFields } ... on Post { title } } } fragment UserFields on User { id name }`,
+		},
+		{
+			name:          "declared variable",
+			operation:     Subscription("OnCreate").Declare("owner", "String").Select(Field("onCreate").Arg("owner", Variable("owner")).Select(Field("id"))),
+			wantQuery:     `subscription OnCreate($owner: String) { onCreate(owner: $owner) { id } }`,
+			wantVariables: "",
+		},
+		{
+			name:      "missing required variable",
+			operation: Query("Get").Declare("id", "ID!").Select(Field("get").Arg("id", Variable("id"))),
+			wantErr:   true,
+		},
+		{
+			name:      "invalid field name",
+			operation: Query("").Select(Field("user { password }")),
+			wantErr:   true,
+		},
+		{
+			name:      "invalid type",
+			operation: Query("").Var("id", "ID!) { x }", "1").Select(Field("get")),
+			wantErr:   true,
+		},
+		{
+			name:      "invalid enum",
+			operation: Query("").Select(Field("list").Arg("order", Enum("DESC) { x }"))),
+			wantErr:   true,
+		},
+		{
+			name:      "no selections",
+			operation: Query("Empty"),
+			wantErr:   true,
+		},
+		{
+			name:      "conflicting fragments",
+			operation: Query("").Select(Spread(NewFragment("F", "User", Field("id"))), Spread(NewFragment("F", "User", Field("name")))),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.operation.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Query != tt.wantQuery {
+				t.Errorf("query:\nwant: %s\ngot:  %s", tt.wantQuery, got.Query)
+			}
+			variables := ""
+			if got.Variables != nil {
+				variables = string(*got.Variables)
+			}
+			if variables != tt.wantVariables {
+				t.Errorf("variables: want %s, got %s", tt.wantVariables, variables)
+			}
+			if tt.operation.name != "" && (got.OperationName == nil || *got.OperationName != tt.operation.name) {
+				t.Errorf("operation name: %v", got.OperationName)
+			}
+		})
+	}
+}
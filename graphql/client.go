@@ -2,12 +2,15 @@ package graphql
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
@@ -15,12 +18,29 @@ import (
 
 type httpStatusError struct {
 	StatusCode int
+	// Errors are the GraphQL errors in the response body, such as the reason of an authorization failure.
+	Errors []interface{}
 }
 
 func (e httpStatusError) Error() string {
 	return http.StatusText(e.StatusCode)
 }
 
+// responseErrors returns the status text followed by the GraphQL errors of the response body.
+func (e httpStatusError) responseErrors() *[]interface{} {
+	errs := append([]interface{}{e.Error()}, e.Errors...)
+	return &errs
+}
+
+// decodeErrors returns the GraphQL errors of a JSON error response body, or nil.
+func decodeErrors(body io.Reader) []interface{} {
+	response := Response{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil || response.Errors == nil {
+		return nil
+	}
+	return *response.Errors
+}
+
 func (e httpStatusError) shouldRetry() bool {
 	return e.StatusCode == http.StatusInternalServerError ||
 		e.StatusCode == http.StatusServiceUnavailable
@@ -33,6 +53,8 @@ type Client struct {
 	maxElapsedTime time.Duration
 	header         http.Header
 	http           *http.Client
+	maxGETURL      int
+	gzipMinSize    int
 }
 
 // NewClient returns a Client instance.
@@ -43,6 +65,7 @@ func NewClient(endpoint string, opts ...ClientOption) *Client {
 		maxElapsedTime: time.Duration(20 * time.Second),
 		header:         map[string][]string{},
 		http:           &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		gzipMinSize:    -1,
 	}
 
 	for _, opt := range opts {
@@ -74,12 +97,12 @@ func (c *Client) PostAsync(header http.Header, request PostRequest, callback fun
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.endpoint, bytes.NewBuffer(jsonBytes))
+	req, err := c.newRequest(context.Background(), header, request, jsonBytes)
 	if err != nil {
 		slog.Error("unable to create request", "error", err)
 		return nil, err
 	}
-	req.Header = merge(req.Header, merge(c.header, header))
+	c.setHeader(req, header)
 
 	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
 	req = req.WithContext(ctx)
@@ -109,6 +132,7 @@ func (c *Client) PostAsync(header http.Header, request PostRequest, callback fun
 					slog.Error("unable to send request", "error", httpErr, "request", request)
 					return "", httpErr
 				}
+				httpErr.Errors = decodeErrors(r.Body)
 				return "", backoff.Permanent(httpErr)
 			}
 
@@ -130,7 +154,7 @@ func (c *Client) PostAsync(header http.Header, request PostRequest, callback fun
 		case err == nil:
 			callback(&response, nil)
 		case errors.As(err, &httpErr):
-			callback(&Response{&(httpErr.StatusCode), nil, &[]interface{}{httpErr.Error()}, nil}, nil)
+			callback(&Response{&(httpErr.StatusCode), nil, httpErr.responseErrors(), nil}, nil)
 		default:
 			callback(nil, err)
 		}
@@ -139,6 +163,71 @@ func (c *Client) PostAsync(header http.Header, request PostRequest, callback fun
 	return cancel, nil
 }
 
+// newRequest creates a GET request for queries when enabled and the URL is short enough, a POST request otherwise.
+func (c *Client) newRequest(ctx context.Context, header http.Header, request PostRequest, body []byte) (*http.Request, error) {
+	// A signature version 4 authorization signs the POST body, so signed requests are never turned into GETs.
+	if c.maxGETURL > 0 && request.OperationType() == "query" && !isSigV4(merge(c.header, header)) {
+		u, err := url.Parse(c.endpoint)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		q.Set("query", request.Query)
+		if request.OperationName != nil {
+			q.Set("operationName", *request.OperationName)
+		}
+		if request.Variables != nil {
+			q.Set("variables", string(*request.Variables))
+		}
+		if request.Extensions != nil {
+			q.Set("extensions", string(*request.Extensions))
+		}
+		u.RawQuery = q.Encode()
+		if len(u.String()) <= c.maxGETURL {
+			return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		}
+		slog.Debug("falling back to POST", "length", len(u.String()))
+	}
+
+	if c.gzipMinSize < 0 || len(body) < c.gzipMinSize {
+		return http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewBuffer(body))
+	}
+	if isSigV4(merge(c.header, header)) {
+		return nil, errGzipSigV4
+	}
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Encoding", "gzip")
+	return req, nil
+}
+
+var errGzipSigV4 = errors.New("gzip compression cannot be used with IAM authorization, which signs the uncompressed body")
+
+// isSigV4 reports whether the header carries a signature version 4 authorization.
+func isSigV4(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+}
+
+// setHeader adds the headers of the client and the request to req. A Host header overrides the host of req,
+// since it is ignored by http.Client.
+func (c *Client) setHeader(req *http.Request, header http.Header) {
+	req.Header = merge(req.Header, merge(c.header, header))
+	if host := req.Header.Get("Host"); len(host) != 0 {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+}
+
 func merge(h1, h2 http.Header) http.Header {
 	h := h1.Clone()
 	for k, vv := range h2 {
@@ -1,8 +1,12 @@
 package graphql
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -185,6 +189,29 @@ func TestUnauthorizedError(t *testing.T) {
 	}
 }
 
+func TestErrorResponseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors": [{"errorType": "InvalidSignatureException", "message": "Signature expired"}]}`))
+	}))
+	defer server.Close()
+
+	forbidden := http.StatusForbidden
+	errs := []interface{}{
+		http.StatusText(forbidden),
+		map[string]interface{}{"errorType": "InvalidSignatureException", "message": "Signature expired"},
+	}
+	want := Response{StatusCode: &forbidden, Errors: &errs}
+
+	client := NewClient(server.URL, WithMaxElapsedTime(1*time.Microsecond))
+	got, err := client.Post(http.Header{}, PostRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(t, &want, got)
+}
+
 func TestTimeout(t *testing.T) {
 	server := newDelayServer(3 * time.Microsecond)
 	defer server.Close()
@@ -239,3 +266,127 @@ func TestCancel(t *testing.T) {
 	}
 	checkCanceledError(t, <-errCh)
 }
+
+func TestRequestEncoding(t *testing.T) {
+	type received struct {
+		method   string
+		encoding string
+		request  PostRequest
+	}
+	ch := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := received{method: r.Method, encoding: r.Header.Get("Content-Encoding")}
+		if r.Method == http.MethodGet {
+			q := r.URL.Query()
+			got.request.Query = q.Get("query")
+			if q.Has("operationName") {
+				name := q.Get("operationName")
+				got.request.OperationName = &name
+			}
+			if q.Has("variables") {
+				v := json.RawMessage(q.Get("variables"))
+				got.request.Variables = &v
+			}
+			if q.Has("extensions") {
+				e := json.RawMessage(q.Get("extensions"))
+				got.request.Extensions = &e
+			}
+		} else {
+			var body io.Reader = r.Body
+			if got.encoding == "gzip" {
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				body = zr
+			}
+			if err := json.NewDecoder(body).Decode(&got.request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		ch <- got
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	name := "Get"
+	variables := json.RawMessage(`{"id":"1"}`)
+	extensions := json.RawMessage(`{"persistedQuery":{"version":1}}`)
+	query := PostRequest{Query: "query Get($id: ID!) { get(id: $id) }", OperationName: &name, Variables: &variables, Extensions: &extensions}
+	mutation := PostRequest{Query: "mutation { put(value: \"" + strings.Repeat("x", 512) + "\") }"}
+	long := PostRequest{Query: "query { get(id: \"" + strings.Repeat("x", 4096) + "\") }"}
+	commented := PostRequest{Query: "# put\nmutation { put }"}
+
+	tests := []struct {
+		name         string
+		opts         []ClientOption
+		header       http.Header
+		request      PostRequest
+		wantMethod   string
+		wantEncoding string
+	}{
+		{name: "post by default", request: query, wantMethod: http.MethodPost},
+		{name: "get query", opts: []ClientOption{WithGETQueries(2048)}, request: query, wantMethod: http.MethodGet},
+		{name: "post mutation", opts: []ClientOption{WithGETQueries(2048)}, request: mutation, wantMethod: http.MethodPost},
+		{name: "post commented mutation", opts: []ClientOption{WithGETQueries(2048)}, request: commented, wantMethod: http.MethodPost},
+		{name: "post long query", opts: []ClientOption{WithGETQueries(2048)}, request: long, wantMethod: http.MethodPost},
+		{name: "post signed query", opts: []ClientOption{WithGETQueries(2048)}, request: query, wantMethod: http.MethodPost,
+			header: http.Header{"Authorization": {"AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/appsync/aws4_request"}}},
+		{name: "gzip large body", opts: []ClientOption{WithGzipRequests(256)}, request: mutation, wantMethod: http.MethodPost, wantEncoding: "gzip"},
+		{name: "no gzip small body", opts: []ClientOption{WithGzipRequests(256)}, request: query, wantMethod: http.MethodPost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			response, err := NewClient(server.URL, tt.opts...).Post(header, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *response.StatusCode != http.StatusOK {
+				t.Fatal(*response.StatusCode)
+			}
+			got := <-ch
+			if got.method != tt.wantMethod {
+				t.Errorf("method: want %s, got %s", tt.wantMethod, got.method)
+			}
+			if got.encoding != tt.wantEncoding {
+				t.Errorf("encoding: want %q, got %q", tt.wantEncoding, got.encoding)
+			}
+			if !reflect.DeepEqual(got.request, tt.request) {
+				t.Errorf("request: want %+v, got %+v", tt.request, got.request)
+			}
+		})
+	}
+}
+
+func TestGzipSigV4(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKID/20240102/us-east-1/appsync/aws4_request")
+	request := PostRequest{Query: "mutation { put(value: \"" + strings.Repeat("x", 512) + "\") }"}
+	if _, err := NewClient("http://localhost", WithGzipRequests(256)).Post(header, request); err != errGzipSigV4 {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestHostHeader(t *testing.T) {
+	hosts := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host + " " + r.Header.Get("Host")
+		_, _ = w.Write([]byte(`{"data":{"message":"hi"}}`))
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Host", "api.example.com")
+	if _, err := NewClient(server.URL).Post(header, PostRequest{Query: "query { message }"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-hosts; got != "api.example.com " {
+		t.Errorf("got: %q", got)
+	}
+}
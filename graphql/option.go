@@ -58,3 +58,21 @@ func WithHTTPHeader(header http.Header) ClientOption {
 		c.header = merge(c.header, header)
 	}
 }
+
+// WithGETQueries returns a ClientOption sending queries via HTTP GET with URL-encoded parameters,
+// so that they can be cached by CDNs. Mutations, subscriptions, requests whose operation type cannot be determined
+// and queries whose URL would exceed maxURLLength are sent via POST, and so are requests signed with IAM authorization,
+// which signs the POST body.
+func WithGETQueries(maxURLLength int) ClientOption {
+	return func(c *Client) {
+		c.maxGETURL = maxURLLength
+	}
+}
+
+// WithGzipRequests returns a ClientOption compressing POST bodies of at least minSize bytes with gzip.
+// IAM signatures cover the uncompressed body, so compressing IAM signed requests fails with an error.
+func WithGzipRequests(minSize int) ClientOption {
+	return func(c *Client) {
+		c.gzipMinSize = minSize
+	}
+}
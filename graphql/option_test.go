@@ -156,3 +156,29 @@ func TestWithHTTPHeader(t *testing.T) {
 	}
 
 }
+
+func TestWithGETQueries(t *testing.T) {
+	client := NewClient(testEndpoint)
+	opt := WithGETQueries(2048)
+	if client.maxGETURL != 0 {
+		t.Fatal(client.maxGETURL)
+	}
+
+	opt(client)
+	if client.maxGETURL != 2048 {
+		t.Fatal(client.maxGETURL)
+	}
+}
+
+func TestWithGzipRequests(t *testing.T) {
+	client := NewClient(testEndpoint)
+	opt := WithGzipRequests(1024)
+	if client.gzipMinSize != -1 {
+		t.Fatal(client.gzipMinSize)
+	}
+
+	opt(client)
+	if client.gzipMinSize != 1024 {
+		t.Fatal(client.gzipMinSize)
+	}
+}
@@ -10,6 +10,7 @@ type PostRequest struct {
 	Query         string           `json:"query"`
 	OperationName *string          `json:"operationName"`
 	Variables     *json.RawMessage `json:"variables"`
+	Extensions    *json.RawMessage `json:"extensions,omitempty"`
 }
 
 // IsQuery checks if the Request is "Query" or not.
@@ -26,3 +27,139 @@ func (p *PostRequest) IsMutation() bool {
 func (p *PostRequest) IsSubscription() bool {
 	return strings.HasPrefix(strings.TrimSpace(p.Query), "subscription")
 }
+
+// OperationType returns "query", "mutation" or "subscription", the type of the operation selected by OperationName,
+// or of the only operation of the document otherwise. Unlike IsQuery, IsMutation and IsSubscription,
+// it skips comments and fragment definitions. It returns "" if the operation cannot be determined.
+func (p *PostRequest) OperationType() string {
+	type operation struct{ typ, name string }
+	doc := p.Query
+	operations := []operation{}
+	i := skipIgnored(doc, 0)
+	for i < len(doc) {
+		if doc[i] == '{' {
+			// A query shorthand.
+			operations = append(operations, operation{"query", ""})
+			i = skipIgnored(doc, skipBalanced(doc, i))
+			continue
+		}
+		keyword, next := readName(doc, i)
+		if len(keyword) == 0 {
+			return ""
+		}
+		op := operation{typ: keyword}
+		switch keyword {
+		case "query", "mutation", "subscription":
+			op.name, _ = readName(doc, skipIgnored(doc, next))
+		case "fragment":
+		default:
+			return ""
+		}
+		// Skip the name, variables and directives up to the selection set.
+		i = next
+		for i < len(doc) && doc[i] != '{' {
+			if doc[i] == '(' || doc[i] == '#' || doc[i] == '"' {
+				i = skipIgnored(doc, skipBalanced(doc, i))
+				continue
+			}
+			i++
+		}
+		if i == len(doc) {
+			return ""
+		}
+		i = skipIgnored(doc, skipBalanced(doc, i))
+		if keyword != "fragment" {
+			operations = append(operations, op)
+		}
+	}
+
+	for _, op := range operations {
+		if p.OperationName != nil && op.name == *p.OperationName {
+			return op.typ
+		}
+	}
+	if p.OperationName == nil && len(operations) == 1 {
+		return operations[0].typ
+	}
+	return ""
+}
+
+// skipIgnored returns the index of the first token at or after i, skipping whitespace, commas and comments.
+func skipIgnored(doc string, i int) int {
+	for i < len(doc) {
+		switch doc[i] {
+		case ' ', '\t', '\n', '\r', ',':
+			i++
+		case '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		default:
+			if strings.HasPrefix(doc[i:], "\ufeff") {
+				i += len("\ufeff")
+				continue
+			}
+			return i
+		}
+	}
+	return i
+}
+
+// skipBalanced returns the index after the block, string or comment starting at i,
+// skipping nested brackets, strings and comments.
+func skipBalanced(doc string, i int) int {
+	depth := 0
+	for i < len(doc) {
+		switch {
+		case doc[i] == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], `"""`):
+			i += 3
+			for i < len(doc) && !strings.HasPrefix(doc[i:], `"""`) {
+				if strings.HasPrefix(doc[i:], `\"""`) {
+					i += 4
+					continue
+				}
+				i++
+			}
+			i += 3
+		case doc[i] == '"':
+			i++
+			for i < len(doc) && doc[i] != '"' && doc[i] != '\n' {
+				if doc[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case strings.IndexByte("({[", doc[i]) >= 0:
+			depth++
+			i++
+		case strings.IndexByte(")}]", doc[i]) >= 0:
+			depth--
+			i++
+		default:
+			i++
+		}
+		if depth <= 0 {
+			return min(i, len(doc))
+		}
+	}
+	return len(doc)
+}
+
+// readName returns the name starting at i and the index after it, or "" if there is none.
+func readName(doc string, i int) (string, int) {
+	start := i
+	for i < len(doc) {
+		c := doc[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > start && '0' <= c && c <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+	return doc[start:i], i
+}
@@ -36,3 +36,40 @@ func TestIsSubscription(t *testing.T) {
 		t.Fatalf("%+v", request)
 	}
 }
+
+func TestOperationType(t *testing.T) {
+	name := func(s string) *string { return &s }
+	tests := []struct {
+		name          string
+		query         string
+		operationName *string
+		want          string
+	}{
+		{name: "query", query: "query Get($id: ID!) { get(id: $id) }", want: "query"},
+		{name: "shorthand", query: "{ get }", want: "query"},
+		{name: "mutation after comment", query: "# fetch\nmutation { put }", want: "mutation"},
+		{name: "mutation after fragment", query: "fragment F on T { id }\nmutation { put { ...F } }", want: "mutation"},
+		{name: "subscription with directives", query: `subscription OnPut @aws_iam { onPut(filter: "}") { id } }`, want: "subscription"},
+		{name: "default values", query: `mutation Put($v: Input = {a: "{"}) { put(v: $v) }`, want: "mutation"},
+		{name: "block string", query: `query { get(s: """ mutation { x } \""" """) }`, want: "query"},
+		{
+			name:          "operation name",
+			query:         "query Get { get } mutation Put { put }",
+			operationName: name("Put"),
+			want:          "mutation",
+		},
+		{name: "ambiguous", query: "query Get { get } mutation Put { put }"},
+		{name: "unknown operation name", query: "query Get { get }", operationName: name("Put")},
+		{name: "empty"},
+		{name: "invalid", query: "foo { bar }"},
+		{name: "unterminated", query: "query Get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := PostRequest{Query: tt.query, OperationName: tt.operationName}
+			if got := request.OperationType(); got != tt.want {
+				t.Errorf("want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
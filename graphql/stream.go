@@ -0,0 +1,336 @@
+package graphql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const streamAccept = "multipart/mixed;deferSpec=20220824, text/event-stream, application/json"
+
+var errStopStream = errors.New("stream stopped")
+
+type incrementalResult struct {
+	ID         string        `json:"id"`
+	Path       []interface{} `json:"path"`
+	SubPath    []interface{} `json:"subPath"`
+	Data       interface{}   `json:"data"`
+	Items      []interface{} `json:"items"`
+	Errors     []interface{} `json:"errors"`
+	Extensions interface{}   `json:"extensions"`
+}
+
+type pendingResult struct {
+	ID   string        `json:"id"`
+	Path []interface{} `json:"path"`
+}
+
+type streamPayload struct {
+	Data        interface{}         `json:"data"`
+	Errors      []interface{}       `json:"errors"`
+	Extensions  interface{}         `json:"extensions"`
+	HasNext     *bool               `json:"hasNext"`
+	Pending     []pendingResult     `json:"pending"`
+	Incremental []incrementalResult `json:"incremental"`
+}
+
+// streamAccumulator merges incremental payloads into the accumulated result.
+type streamAccumulator struct {
+	statusCode int
+	data       interface{}
+	errors     []interface{}
+	extensions interface{}
+	pending    map[string][]interface{}
+}
+
+func (a *streamAccumulator) apply(p *streamPayload) *Response {
+	if p.Data != nil || p.Incremental == nil && p.HasNext == nil {
+		// An initial payload, or an independent subscription event.
+		a.data, a.errors, a.extensions = p.Data, nil, nil
+		a.pending = map[string][]interface{}{}
+	}
+	for _, pr := range p.Pending {
+		a.pending[pr.ID] = pr.Path
+	}
+	a.errors = append(a.errors, p.Errors...)
+	if p.Extensions != nil {
+		a.extensions = p.Extensions
+	}
+	for _, inc := range p.Incremental {
+		path := inc.Path
+		if len(inc.ID) != 0 {
+			path = append(append([]interface{}{}, a.pending[inc.ID]...), inc.SubPath...)
+		}
+		if inc.Data != nil {
+			a.data = mergeAt(a.data, path, inc.Data)
+		}
+		if inc.Items != nil {
+			a.data = appendItemsAt(a.data, path, inc.Items, len(inc.ID) == 0)
+		}
+		a.errors = append(a.errors, inc.Errors...)
+		if inc.Extensions != nil {
+			a.extensions = inc.Extensions
+		}
+	}
+
+	statusCode := a.statusCode
+	response := &Response{StatusCode: &statusCode, Data: a.data}
+	if len(a.errors) != 0 {
+		errs := append([]interface{}{}, a.errors...)
+		response.Errors = &errs
+	}
+	if a.extensions != nil {
+		ext := a.extensions
+		response.Extensions = &ext
+	}
+	return response
+}
+
+// mergeAt deep merges v into the value of data at path.
+func mergeAt(data interface{}, path []interface{}, v interface{}) interface{} {
+	return replaceAt(data, path, deepMerge(lookupPath(data, path), v))
+}
+
+func deepMerge(dst, src interface{}) interface{} {
+	d, ok1 := dst.(map[string]interface{})
+	s, ok2 := src.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return src
+	}
+	for k, v := range s {
+		d[k] = deepMerge(d[k], v)
+	}
+	return d
+}
+
+// appendItemsAt appends items to the list at path.
+// With indexed set, the last path element is the index of the first item, as in the 2022 @stream format.
+func appendItemsAt(data interface{}, path []interface{}, items []interface{}, indexed bool) interface{} {
+	if indexed && len(path) != 0 {
+		if i, ok := path[len(path)-1].(float64); ok {
+			list, _ := lookupPath(data, path[:len(path)-1]).([]interface{})
+			for len(list) < int(i) {
+				list = append(list, nil)
+			}
+			list = append(list[:int(i)], items...)
+			return replaceAt(data, path[:len(path)-1], list)
+		}
+	}
+	list, _ := lookupPath(data, path).([]interface{})
+	return replaceAt(data, path, append(list, items...))
+}
+
+func lookupPath(data interface{}, path []interface{}) interface{} {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := data.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			data = m[key]
+		case float64:
+			l, ok := data.([]interface{})
+			if !ok || int(key) >= len(l) {
+				return nil
+			}
+			data = l[int(key)]
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+func replaceAt(data interface{}, path []interface{}, v interface{}) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+	switch key := path[0].(type) {
+	case string:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		m[key] = replaceAt(m[key], path[1:], v)
+		return m
+	case float64:
+		l, _ := data.([]interface{})
+		i := int(key)
+		for len(l) <= i {
+			l = append(l, nil)
+		}
+		l[i] = replaceAt(l[i], path[1:], v)
+		return l
+	}
+	slog.Warn("invalid path element", "element", path[0])
+	return data
+}
+
+// PostStream is a GraphQL POST request accepting streamed responses.
+//
+// application/json responses are delivered once. text/event-stream responses deliver each event,
+// and multipart/mixed responses deliver the result accumulated from the initial and incremental
+// @defer/@stream payloads after each part, updating the accumulated data in place. PostStream returns when the stream ends, ctx is done
+// or callback returns an error. The client timeout does not apply to streams.
+func (c *Client) PostStream(ctx context.Context, header http.Header, request PostRequest, callback func(*Response) error) error {
+	jsonBytes, err := json.Marshal(request)
+	if err != nil {
+		slog.Error("unable to marshal request", "error", err, "request", request)
+		return err
+	}
+	req, err := c.newRequest(ctx, header, request, jsonBytes)
+	if err != nil {
+		slog.Error("unable to create request", "error", err)
+		return err
+	}
+	c.setHeader(req, header)
+	if req.Method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", streamAccept)
+
+	r, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("unable to send request", "error", err, "request", request)
+		return err
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("unable to close response body", "error", err, "request", request)
+		}
+	}()
+
+	if r.StatusCode != http.StatusOK {
+		httpErr := httpStatusError{StatusCode: r.StatusCode, Errors: decodeErrors(r.Body)}
+		return callback(&Response{&(httpErr.StatusCode), nil, httpErr.responseErrors(), nil})
+	}
+
+	acc := &streamAccumulator{statusCode: r.StatusCode, pending: map[string][]interface{}{}}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "application/json"
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return readEventStream(r.Body, acc, callback)
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return readMultipart(r.Body, params["boundary"], acc, callback)
+	default:
+		payload := new(streamPayload)
+		if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+			slog.Error("unable to decode response", "error", err, "request", request)
+			return err
+		}
+		return callback(acc.apply(payload))
+	}
+}
+
+// Stream returns an iterator over the responses of PostStream.
+func (c *Client) Stream(ctx context.Context, header http.Header, request PostRequest) iter.Seq2[*Response, error] {
+	return func(yield func(*Response, error) bool) {
+		err := c.PostStream(ctx, header, request, func(r *Response) error {
+			if !yield(r, nil) {
+				return errStopStream
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopStream) {
+			yield(nil, err)
+		}
+	}
+}
+
+func readEventStream(body io.Reader, acc *streamAccumulator, callback func(*Response) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	event, data := "", []string{}
+	// dispatch handles the event read so far, reporting whether the stream is complete.
+	dispatch := func() (bool, error) {
+		defer func() { event, data = "", []string{} }()
+		switch event {
+		case "complete":
+			return true, nil
+		case "", "message", "next":
+			if len(data) == 0 {
+				return false, nil
+			}
+			payload := new(streamPayload)
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), payload); err != nil {
+				slog.Error("unable to decode event", "error", err, "data", data)
+				return false, err
+			}
+			return false, callback(acc.apply(payload))
+		}
+		return false, nil
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) != 0 {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+		if complete, err := dispatch(); complete || err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	// The last event may not be terminated by a blank line.
+	_, err := dispatch()
+	return err
+}
+
+func readMultipart(body io.Reader, boundary string, acc *streamAccumulator, callback func(*Response) error) error {
+	if len(boundary) == 0 {
+		return fmt.Errorf("multipart boundary is missing")
+	}
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			slog.Error("unable to read part", "error", err)
+			return err
+		}
+		b, err := io.ReadAll(part)
+		if err != nil {
+			slog.Error("unable to read part", "error", err)
+			return err
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		payload := new(streamPayload)
+		if err := json.Unmarshal(b, payload); err != nil {
+			slog.Error("unable to decode part", "error", err, "part", string(b))
+			return err
+		}
+		if err := callback(acc.apply(payload)); err != nil {
+			return err
+		}
+		if payload.HasNext != nil && !*payload.HasNext {
+			return nil
+		}
+	}
+}
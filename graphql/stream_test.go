@@ -0,0 +1,226 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newStreamServer(contentType string, chunks []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != streamAccept {
+			http.Error(w, "invalid accept header", http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func multipartChunks(boundary string, parts ...string) []string {
+	chunks := []string{}
+	for _, p := range parts {
+		chunks = append(chunks, fmt.Sprintf("\r\n--%s\r\nContent-Type: application/json; charset=utf-8\r\n\r\n%s", boundary, p))
+	}
+	return append(chunks, fmt.Sprintf("\r\n--%s--\r\n", boundary))
+}
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestClient_PostStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		chunks      []string
+		want        []string
+		wantErrors  int
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			chunks:      []string{`{"data":{"message":"hello"}}`},
+			want:        []string{`{"message":"hello"}`},
+		},
+		{
+			name:        "event stream",
+			contentType: "text/event-stream",
+			chunks: []string{
+				": keep-alive\n\n",
+				"event: next\ndata: {\"data\":{\"count\":1}}\n\n",
+				"event: next\ndata: {\"data\":\n",
+				"data: {\"count\":2}}\n\n",
+				"event: complete\ndata:\n\n",
+				"event: next\ndata: {\"data\":{\"count\":3}}\n\n",
+			},
+			want: []string{`{"count":1}`, `{"count":2}`},
+		},
+		{
+			name:        "event stream without trailing blank line",
+			contentType: "text/event-stream",
+			chunks: []string{
+				"event: next\ndata: {\"data\":{\"count\":1}}\n\n",
+				"event: next\ndata: {\"data\":{\"count\":2}}\n",
+			},
+			want: []string{`{"count":1}`, `{"count":2}`},
+		},
+		{
+			name:        "multipart defer and stream",
+			contentType: `multipart/mixed; boundary="-"; deferSpec=20220824`,
+			chunks: multipartChunks("-",
+				`{"data":{"user":{"id":"1","friends":[{"id":"a"}]}},"hasNext":true}`,
+				`{"incremental":[{"data":{"name":"Alice"},"path":["user"],"label":"profile"}],"hasNext":true}`,
+				`{"incremental":[{"items":[{"id":"b"},{"id":"c"}],"path":["user","friends",1]}],"hasNext":true}`,
+				`{"incremental":[{"errors":[{"message":"boom"}],"path":["user","friends",3]}],"hasNext":false}`,
+			),
+			want: []string{
+				`{"user":{"id":"1","friends":[{"id":"a"}]}}`,
+				`{"user":{"id":"1","name":"Alice","friends":[{"id":"a"}]}}`,
+				`{"user":{"id":"1","name":"Alice","friends":[{"id":"a"},{"id":"b"},{"id":"c"}]}}`,
+				`{"user":{"id":"1","name":"Alice","friends":[{"id":"a"},{"id":"b"},{"id":"c"}]}}`,
+			},
+			wantErrors: 1,
+		},
+		{
+			name:        "multipart pending ids",
+			contentType: `multipart/mixed; boundary="graphql"`,
+			chunks: multipartChunks("graphql",
+				`{"data":{"user":{"id":"1","posts":[]}},"pending":[{"id":"0","path":["user"]},{"id":"1","path":["user","posts"]}],"hasNext":true}`,
+				`{"incremental":[{"id":"0","data":{"bio":"hi"},"subPath":["profile"]},{"id":"1","items":["p1"]}],"completed":[{"id":"0"}],"hasNext":true}`,
+				`{"completed":[{"id":"1"}],"hasNext":false}`,
+			),
+			want: []string{
+				`{"user":{"id":"1","posts":[]}}`,
+				`{"user":{"id":"1","profile":{"bio":"hi"},"posts":["p1"]}}`,
+				`{"user":{"id":"1","profile":{"bio":"hi"},"posts":["p1"]}}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStreamServer(tt.contentType, tt.chunks)
+			defer s.Close()
+
+			var got []*Response
+			err := NewClient(s.URL).PostStream(context.Background(), http.Header{}, PostRequest{Query: "query { user { id } }"},
+				func(r *Response) error {
+					// The accumulated data is updated in place, so take a snapshot.
+					b, err := json.Marshal(r)
+					if err != nil {
+						return err
+					}
+					snapshot := new(Response)
+					if err := json.Unmarshal(b, snapshot); err != nil {
+						return err
+					}
+					got = append(got, snapshot)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %d responses, got %d", len(tt.want), len(got))
+			}
+			for i, want := range tt.want {
+				if *got[i].StatusCode != http.StatusOK {
+					t.Errorf("StatusCode is %d", *got[i].StatusCode)
+				}
+				if !reflect.DeepEqual(got[i].Data, decode(t, want)) {
+					t.Errorf("response %d: want %s, got %+v", i, want, got[i].Data)
+				}
+			}
+			last := got[len(got)-1]
+			if errs := 0; last.Errors != nil {
+				errs = len(*last.Errors)
+				if errs != tt.wantErrors {
+					t.Errorf("want %d errors, got %d", tt.wantErrors, errs)
+				}
+			} else if tt.wantErrors != 0 {
+				t.Errorf("want %d errors, got none", tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestClient_PostStream_Error(t *testing.T) {
+	s := newInternalServerErrorServer()
+	defer s.Close()
+
+	var got *Response
+	if err := NewClient(s.URL).PostStream(context.Background(), http.Header{}, PostRequest{},
+		func(r *Response) error { got = r; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got.StatusCode != http.StatusInternalServerError || got.Errors == nil {
+		t.Errorf("got: %+v", got)
+	}
+}
+
+func TestClient_PostStream_ErrorBody(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors": [{"errorType": "UnauthorizedException", "message": "Valid authorization header not provided."}]}`))
+	}))
+	defer s.Close()
+
+	var got *Response
+	if err := NewClient(s.URL).PostStream(context.Background(), http.Header{}, PostRequest{},
+		func(r *Response) error { got = r; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got.StatusCode != http.StatusUnauthorized || got.Errors == nil || len(*got.Errors) != 2 {
+		t.Fatalf("got: %+v", got)
+	}
+	if e, ok := (*got.Errors)[1].(map[string]interface{}); !ok || e["errorType"] != "UnauthorizedException" {
+		t.Errorf("got: %v", *got.Errors)
+	}
+}
+
+func TestClient_Stream(t *testing.T) {
+	chunks := []string{}
+	for i := 0; i < 5; i++ {
+		chunks = append(chunks, fmt.Sprintf("data: {\"data\":{\"count\":%d}}\n\n", i))
+	}
+	s := newStreamServer("text/event-stream", chunks)
+	defer s.Close()
+
+	counts := []float64{}
+	for r, err := range NewClient(s.URL).Stream(context.Background(), http.Header{}, PostRequest{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, r.Data.(map[string]interface{})["count"].(float64))
+		if len(counts) == 3 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(counts, []float64{0, 1, 2}) {
+		t.Errorf("got: %v", counts)
+	}
+
+	bad := newStreamServer("text/event-stream", []string{"data: {\n\n"})
+	defer bad.Close()
+	var gotErr error
+	for _, err := range NewClient(bad.URL).Stream(context.Background(), http.Header{}, PostRequest{}) {
+		gotErr = err
+	}
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "unexpected end of JSON input") {
+		t.Errorf("got: %v", gotErr)
+	}
+}
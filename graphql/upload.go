@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upload represents a file uploaded with a request.
+type Upload struct {
+	// Filename is the name of the file.
+	Filename string
+	// ContentType is the media type of the file, application/octet-stream if empty.
+	ContentType string
+	// Size is the length of the file in bytes, required by presigned S3 PUT URLs. Zero means unknown.
+	Size int64
+	// Reader streams the content of the file.
+	Reader io.Reader
+}
+
+func (u Upload) contentType() string {
+	if len(u.ContentType) == 0 {
+		return "application/octet-stream"
+	}
+	return u.ContentType
+}
+
+// PresignedUpload represents a presigned upload target such as an S3 presigned PUT URL or POST policy.
+type PresignedUpload struct {
+	// URL is the presigned URL.
+	URL string
+	// Method is PUT or POST, PUT if empty.
+	Method string
+	// Header is added to the upload request.
+	Header http.Header
+	// Fields are the form fields of a presigned POST policy, sent before the file.
+	Fields map[string]string
+}
+
+// PostMultipart is a synchronous GraphQL request uploading files following the GraphQL multipart
+// request specification. files maps object paths of the request, such as "variables.file" or
+// "variables.files.0", to the uploads, which are streamed and set to null in the operations.
+// Multipart requests are not retried and the client timeout does not apply.
+func (c *Client) PostMultipart(ctx context.Context, header http.Header, request PostRequest, files map[string]Upload) (*Response, error) {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var variables interface{}
+	if request.Variables != nil {
+		if err := json.Unmarshal(*request.Variables, &variables); err != nil {
+			slog.Error("unable to unmarshal variables", "error", err)
+			return nil, err
+		}
+	}
+	fileMap := map[string][]string{}
+	for i, p := range paths {
+		segments := strings.Split(p, ".")
+		if segments[0] != "variables" || len(segments) == 1 {
+			return nil, fmt.Errorf("invalid file path %q", p)
+		}
+		variables = setNull(variables, segments[1:])
+		fileMap[strconv.Itoa(i)] = []string{p}
+	}
+	if request.Variables != nil || len(paths) != 0 {
+		b, err := json.Marshal(variables)
+		if err != nil {
+			return nil, err
+		}
+		raw := json.RawMessage(b)
+		request.Variables = &raw
+	}
+	operations, err := json.Marshal(request)
+	if err != nil {
+		slog.Error("unable to marshal request", "error", err, "request", request)
+		return nil, err
+	}
+	bmap, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipartRequest(mw, operations, bmap, paths, files))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, pr)
+	if err != nil {
+		slog.Error("unable to create request", "error", err)
+		_ = pr.Close()
+		return nil, err
+	}
+	c.setHeader(req, header)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	r, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("unable to send request", "error", err, "request", request)
+		return nil, err
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("unable to close response body", "error", err, "request", request)
+		}
+	}()
+	if r.StatusCode != http.StatusOK {
+		httpErr := httpStatusError{StatusCode: r.StatusCode, Errors: decodeErrors(r.Body)}
+		return &Response{&(httpErr.StatusCode), nil, httpErr.responseErrors(), nil}, nil
+	}
+	response := new(Response)
+	if err := json.NewDecoder(r.Body).Decode(response); err != nil {
+		slog.Error("unable to decode response", "error", err, "request", request)
+		return nil, err
+	}
+	response.StatusCode = &r.StatusCode
+	return response, nil
+}
+
+func writeMultipartRequest(mw *multipart.Writer, operations, fileMap []byte, paths []string, files map[string]Upload) error {
+	if err := mw.WriteField("operations", string(operations)); err != nil {
+		return err
+	}
+	if err := mw.WriteField("map", string(fileMap)); err != nil {
+		return err
+	}
+	for i, p := range paths {
+		if err := writeFilePart(mw, strconv.Itoa(i), files[p]); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeFilePart(mw *multipart.Writer, field string, file Upload) error {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, field, escapeQuotes(file.Filename)))
+	h.Set("Content-Type", file.contentType())
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file.Reader)
+	return err
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// setNull sets null at the path of v, creating the intermediate objects and lists.
+func setNull(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return nil
+	}
+	if i, err := strconv.Atoi(path[0]); err == nil {
+		if l, ok := v.([]interface{}); ok || v == nil {
+			for len(l) <= i {
+				l = append(l, nil)
+			}
+			l[i] = setNull(l[i], path[1:])
+			return l
+		}
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+	}
+	m[path[0]] = setNull(m[path[0]], path[1:])
+	return m
+}
+
+// UploadPresigned uploads the file to the presigned target with the client's HTTP stack.
+func (c *Client) UploadPresigned(ctx context.Context, target PresignedUpload, file Upload) error {
+	method := target.Method
+	if len(method) == 0 {
+		method = http.MethodPut
+	}
+
+	var req *http.Request
+	var err error
+	switch method {
+	case http.MethodPut:
+		req, err = http.NewRequestWithContext(ctx, method, target.URL, file.Reader)
+		if err != nil {
+			slog.Error("unable to create request", "error", err)
+			return err
+		}
+		if file.Size > 0 {
+			req.ContentLength = file.Size
+		}
+		if len(file.ContentType) != 0 {
+			req.Header.Set("Content-Type", file.ContentType)
+		}
+	case http.MethodPost:
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		go func() {
+			pw.CloseWithError(writePresignedPost(mw, target.Fields, file))
+		}()
+		req, err = http.NewRequestWithContext(ctx, method, target.URL, pr)
+		if err != nil {
+			slog.Error("unable to create request", "error", err)
+			_ = pr.Close()
+			return err
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+	default:
+		return fmt.Errorf("unsupported upload method %s", method)
+	}
+	for k, vv := range target.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+
+	r, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("unable to upload file", "error", err)
+		return err
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("unable to close response body", "error", err)
+		}
+	}()
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(r.Body, 1024))
+		return fmt.Errorf("upload failed: %s: %s", r.Status, string(b))
+	}
+	return nil
+}
+
+func writePresignedPost(mw *multipart.Writer, fields map[string]string, file Upload) error {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			return err
+		}
+	}
+	if _, ok := fields["Content-Type"]; !ok {
+		if err := mw.WriteField("Content-Type", file.contentType()); err != nil {
+			return err
+		}
+	}
+	if err := writeFilePart(mw, "file", file); err != nil {
+		return err
+	}
+	return mw.Close()
+}
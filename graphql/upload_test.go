@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPostMultipart(t *testing.T) {
+	type received struct {
+		operations string
+		fileMap    string
+		files      map[string]string
+		filenames  map[string]string
+	}
+	ch := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got := received{files: map[string]string{}, filenames: map[string]string{}}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "operations":
+				got.operations = string(b)
+			case "map":
+				got.fileMap = string(b)
+			default:
+				got.files[part.FormName()] = string(b)
+				got.filenames[part.FormName()] = part.FileName()
+			}
+		}
+		ch <- got
+		_, _ = w.Write([]byte(`{"data":{"upload":true}}`))
+	}))
+	defer server.Close()
+
+	variables := json.RawMessage(`{"id":"1","files":[null,null]}`)
+	request := PostRequest{Query: "mutation ($id: ID!, $file: Upload!, $files: [Upload!]!) { upload }", Variables: &variables}
+	files := map[string]Upload{
+		"variables.file":    {Filename: "a.txt", ContentType: "text/plain", Reader: strings.NewReader("a")},
+		"variables.files.0": {Filename: "b.txt", Reader: strings.NewReader("b")},
+		"variables.files.1": {Filename: `"c".txt`, Reader: strings.NewReader("c")},
+	}
+	response, err := NewClient(server.URL).PostMultipart(context.Background(), http.Header{}, request, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *response.StatusCode != http.StatusOK {
+		t.Fatal(*response.StatusCode)
+	}
+
+	got := <-ch
+	operations := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(got.operations), &operations); err != nil {
+		t.Fatal(err)
+	}
+	wantVariables := map[string]interface{}{"id": "1", "file": nil, "files": []interface{}{nil, nil}}
+	if !reflect.DeepEqual(operations["variables"], wantVariables) {
+		t.Errorf("variables: %v", operations["variables"])
+	}
+	if got.fileMap != `{"0":["variables.file"],"1":["variables.files.0"],"2":["variables.files.1"]}` {
+		t.Errorf("map: %s", got.fileMap)
+	}
+	if !reflect.DeepEqual(got.files, map[string]string{"0": "a", "1": "b", "2": "c"}) {
+		t.Errorf("files: %v", got.files)
+	}
+	if got.filenames["2"] != `"c".txt` {
+		t.Errorf("filename: %s", got.filenames["2"])
+	}
+
+	if _, err := NewClient(server.URL).PostMultipart(context.Background(), http.Header{}, request,
+		map[string]Upload{"query": {Reader: strings.NewReader("")}}); err == nil {
+		t.Error("an invalid path should fail")
+	}
+}
+
+func TestPostMultipart_ErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors": [{"message": "file too large"}]}`))
+	}))
+	defer server.Close()
+
+	response, err := NewClient(server.URL).PostMultipart(context.Background(), http.Header{},
+		PostRequest{Query: "mutation ($file: Upload!) { upload }"},
+		map[string]Upload{"variables.file": {Filename: "a.txt", Reader: strings.NewReader("a")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{http.StatusText(http.StatusBadRequest), map[string]interface{}{"message": "file too large"}}
+	if *response.StatusCode != http.StatusBadRequest || response.Errors == nil || !reflect.DeepEqual(*response.Errors, want) {
+		t.Errorf("got: %d, %v", *response.StatusCode, response.Errors)
+	}
+}
+
+func TestUploadPresigned(t *testing.T) {
+	type received struct {
+		method        string
+		contentLength int64
+		contentType   string
+		header        string
+		fields        map[string]string
+		body          string
+	}
+	ch := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := received{method: r.Method, contentLength: r.ContentLength, contentType: r.Header.Get("Content-Type"),
+			header: r.Header.Get("x-amz-acl"), fields: map[string]string{}}
+		if r.Method == http.MethodPost {
+			if err := r.ParseMultipartForm(1024); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for k, v := range r.MultipartForm.Value {
+				got.fields[k] = v[0]
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(f)
+			got.body = string(b)
+			ch <- got
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		got.body = string(b)
+		ch <- got
+		if r.URL.Query().Get("expired") != "" {
+			http.Error(w, "Request has expired", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	tests := []struct {
+		name    string
+		target  PresignedUpload
+		file    Upload
+		want    received
+		wantErr bool
+	}{
+		{
+			name:   "put",
+			target: PresignedUpload{URL: server.URL + "/key?X-Amz-Signature=x", Header: http.Header{"X-Amz-Acl": []string{"private"}}},
+			file:   Upload{ContentType: "image/png", Size: 4, Reader: strings.NewReader("data")},
+			want:   received{method: http.MethodPut, contentLength: 4, contentType: "image/png", header: "private", fields: map[string]string{}, body: "data"},
+		},
+		{
+			name:   "post policy",
+			target: PresignedUpload{URL: server.URL, Method: http.MethodPost, Fields: map[string]string{"key": "uploads/a.txt", "policy": "p"}},
+			file:   Upload{Filename: "a.txt", ContentType: "text/plain", Reader: strings.NewReader("data")},
+			want: received{method: http.MethodPost, contentLength: -1,
+				fields: map[string]string{"key": "uploads/a.txt", "policy": "p", "Content-Type": "text/plain"}, body: "data"},
+		},
+		{
+			name:    "rejected",
+			target:  PresignedUpload{URL: server.URL + "/key?expired=1"},
+			file:    Upload{Size: 4, Reader: strings.NewReader("data")},
+			want:    received{method: http.MethodPut, contentLength: 4, fields: map[string]string{}, body: "data"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.UploadPresigned(context.Background(), tt.target, tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UploadPresigned() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := <-ch
+			if tt.target.Method == http.MethodPost {
+				// The multipart boundary is random.
+				if !strings.HasPrefix(got.contentType, "multipart/form-data") {
+					t.Errorf("content type: %s", got.contentType)
+				}
+				got.contentType = ""
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	operationPattern     = regexp.MustCompile(`\b(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?`)
+	variableDefPattern   = regexp.MustCompile(`\$([_A-Za-z][_0-9A-Za-z]*)\s*:\s*([^=$@]+?)\s*(=|@|$)`)
+	commentPattern       = regexp.MustCompile(`#[^\n]*`)
+	variableDefsSplitter = regexp.MustCompile(`[,\s]+\$`)
+)
+
+// RequestBuilder builds a PostRequest with variables marshalled from Go values.
+type RequestBuilder struct {
+	query         string
+	operationName *string
+	variables     map[string]json.RawMessage
+	err           error
+}
+
+// NewRequest returns a RequestBuilder for the query.
+func NewRequest(query string) *RequestBuilder {
+	return &RequestBuilder{query: query, variables: map[string]json.RawMessage{}}
+}
+
+// OperationName sets the operation name.
+func (b *RequestBuilder) OperationName(name string) *RequestBuilder {
+	b.operationName = &name
+	return b
+}
+
+// Var sets the variable to the JSON encoding of value.
+func (b *RequestBuilder) Var(name string, value interface{}) *RequestBuilder {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		b.setErr(fmt.Errorf("unable to marshal variable %s: %w", name, err))
+		return b
+	}
+	b.variables[name] = raw
+	return b
+}
+
+// Vars sets the variables from the fields of a struct, honoring json tags, or the entries of a map.
+func (b *RequestBuilder) Vars(values interface{}) *RequestBuilder {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		b.setErr(fmt.Errorf("unable to marshal variables: %w", err))
+		return b
+	}
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		b.setErr(fmt.Errorf("variables must be an object: %w", err))
+		return b
+	}
+	for k, v := range m {
+		b.variables[k] = v
+	}
+	return b
+}
+
+func (b *RequestBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the PostRequest, or an error if a variable cannot be marshalled
+// or a required variable of the operation is missing or null.
+func (b *RequestBuilder) Build() (PostRequest, error) {
+	if b.err != nil {
+		return PostRequest{}, b.err
+	}
+	name := ""
+	if b.operationName != nil {
+		name = *b.operationName
+	}
+	for _, required := range RequiredVariables(b.query, name) {
+		v, ok := b.variables[required]
+		if !ok || string(v) == "null" {
+			return PostRequest{}, fmt.Errorf("required variable $%s is missing", required)
+		}
+	}
+
+	request := PostRequest{Query: b.query, OperationName: b.operationName}
+	if len(b.variables) != 0 {
+		raw, err := json.Marshal(b.variables)
+		if err != nil {
+			return PostRequest{}, err
+		}
+		variables := json.RawMessage(raw)
+		request.Variables = &variables
+	}
+	return request, nil
+}
+
+// MustBuild is like Build but panics on errors. It simplifies initializing requests with constant variables.
+func (b *RequestBuilder) MustBuild() PostRequest {
+	request, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return request
+}
+
+// RequiredVariables returns the variables of the operation declared with a non-null type and no default value.
+// The first operation of the query is used when operationName is empty.
+func RequiredVariables(query, operationName string) []string {
+	query = commentPattern.ReplaceAllString(query, "")
+	for _, loc := range operationPattern.FindAllStringSubmatchIndex(query, -1) {
+		if len(operationName) != 0 && (loc[4] < 0 || query[loc[4]:loc[5]] != operationName) {
+			continue
+		}
+		rest := query[loc[1]:]
+		open := strings.IndexAny(rest, "({")
+		if open < 0 || rest[open] == '{' {
+			return nil
+		}
+		end := strings.IndexByte(rest[open:], ')')
+		if end < 0 {
+			return nil
+		}
+		defs := rest[open+1 : open+end]
+
+		required := []string{}
+		for _, def := range variableDefsSplitter.Split(" "+defs, -1) {
+			m := variableDefPattern.FindStringSubmatch("$" + strings.TrimSpace(strings.TrimPrefix(def, "$")))
+			if m == nil {
+				continue
+			}
+			if strings.HasSuffix(strings.TrimSpace(m[2]), "!") && m[3] != "=" {
+				required = append(required, m[1])
+			}
+		}
+		return required
+	}
+	return nil
+}
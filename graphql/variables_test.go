@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequiredVariables(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		want          []string
+	}{
+		{
+			name:  "no variables",
+			query: "query { message }",
+			want:  nil,
+		},
+		{
+			name:  "shorthand",
+			query: "{ search(query: \"x\") }",
+			want:  nil,
+		},
+		{
+			name:  "required and optional",
+			query: "mutation Echo($message: String!, $count: Int, $ids: [ID!]!, $first: Int! = 10 @deprecated) { echo(message: $message) }",
+			want:  []string{"message", "ids"},
+		},
+		{
+			name: "multiline with comments",
+			query: `subscription OnCreate(
+	# the owner
+	$owner: String!
+	$filter: Filter
+) { onCreate(owner: $owner) { id } }`,
+			want: []string{"owner"},
+		},
+		{
+			name:          "named operation",
+			query:         "query A($a: ID!) { a(id: $a) } query B($b: ID!) { b(id: $b) }",
+			operationName: "B",
+			want:          []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequiredVariables(tt.query, tt.operationName)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestBuilder(t *testing.T) {
+	type input struct {
+		Title   string    `json:"title"`
+		Created time.Time `json:"created"`
+		Skip    string    `json:"-"`
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mutation := "mutation Create($input: Input!, $id: ID!, $tags: [String]) { create(id: $id, input: $input) { id } }"
+
+	tests := []struct {
+		name    string
+		builder *RequestBuilder
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "var and vars",
+			builder: NewRequest(mutation).Var("input", input{Title: `"quoted"`, Created: created}).Vars(map[string]interface{}{"id": "1"}),
+			want:    `{"id":"1","input":{"title":"\"quoted\"","created":"2024-01-02T03:04:05Z"}}`,
+		},
+		{
+			name: "vars from struct",
+			builder: NewRequest("query Get($id: ID!) { get(id: $id) }").Vars(struct {
+				ID string `json:"id"`
+			}{"1"}),
+			want: `{"id":"1"}`,
+		},
+		{
+			name:    "missing required variable",
+			builder: NewRequest(mutation).Var("id", "1"),
+			wantErr: true,
+		},
+		{
+			name:    "null required variable",
+			builder: NewRequest(mutation).Var("id", "1").Var("input", nil),
+			wantErr: true,
+		},
+		{
+			name:    "invalid vars",
+			builder: NewRequest(mutation).Vars([]string{"id"}),
+			wantErr: true,
+		},
+		{
+			name:    "unmarshallable var",
+			builder: NewRequest(mutation).Var("id", make(chan int)),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Variables == nil || string(*got.Variables) != tt.want {
+				t.Errorf("want: %s, got: %v", tt.want, got.Variables)
+			}
+		})
+	}
+}
+
+func TestRequestBuilder_MustBuild(t *testing.T) {
+	request := NewRequest("query Message { message }").OperationName("Message").MustBuild()
+	if request.Variables != nil || *request.OperationName != "Message" {
+		t.Errorf("got: %+v", request)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustBuild() should panic")
+		}
+	}()
+	NewRequest("query Get($id: ID!) { get(id: $id) }").MustBuild()
+}
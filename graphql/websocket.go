@@ -0,0 +1,394 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// GraphQLTransportWSProtocol is the websocket subprotocol of the graphql-transport-ws protocol.
+const GraphQLTransportWSProtocol = "graphql-transport-ws"
+
+// ErrWebSocketClosed is returned when the websocket connection is not open.
+var ErrWebSocketClosed = errors.New("websocket connection closed")
+
+// ErrWebSocketConnected is returned when connecting while the websocket connection is already open.
+var ErrWebSocketConnected = errors.New("websocket connection already open")
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// WebSocketSubscription represents an operation running on a WebSocketClient.
+type WebSocketSubscription struct {
+	ID string
+
+	client    *WebSocketClient
+	onReceive func(response *Response)
+	done      chan struct{}
+	once      sync.Once
+	err       error
+}
+
+// Done returns a channel that is closed when the operation completes.
+func (s *WebSocketSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error terminating the operation, if any, once Done is closed.
+func (s *WebSocketSubscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Stop stops the operation.
+func (s *WebSocketSubscription) Stop() {
+	if _, ok := s.client.remove(s.ID); !ok {
+		return
+	}
+	if err := s.client.write(wsMessage{ID: s.ID, Type: "complete"}); err != nil {
+		slog.Warn("unable to write complete", "error", err)
+	}
+	s.finish(nil)
+}
+
+func (s *WebSocketSubscription) finish(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// WebSocketClient is a generic GraphQL over WebSocket client implementing the graphql-transport-ws protocol.
+type WebSocketClient struct {
+	endpoint             string
+	header               http.Header
+	connectionParams     interface{}
+	connectionAckTimeout time.Duration
+	pingInterval         time.Duration
+	dialer               *websocket.Dialer
+	onConnectionLost     func(err error)
+
+	writeMu sync.Mutex
+	ws      *websocket.Conn
+
+	mu            sync.Mutex
+	subscriptions map[string]*WebSocketSubscription
+	closing       bool
+	done          chan struct{}
+}
+
+// WebSocketClientOption represents options for a WebSocketClient.
+type WebSocketClientOption func(*WebSocketClient)
+
+// WithConnectionParams returns a WebSocketClientOption configured with the payload of connection_init.
+func WithConnectionParams(params interface{}) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.connectionParams = params
+	}
+}
+
+// WithConnectionAckTimeout returns a WebSocketClientOption configured with the time to wait for connection_ack.
+func WithConnectionAckTimeout(timeout time.Duration) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.connectionAckTimeout = timeout
+	}
+}
+
+// WithPingInterval returns a WebSocketClientOption configured with the interval of client pings. Zero disables them.
+func WithPingInterval(interval time.Duration) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.pingInterval = interval
+	}
+}
+
+// WithWebSocketHeader returns a WebSocketClientOption configured with the http.Header of the handshake.
+func WithWebSocketHeader(header http.Header) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.header = merge(c.header, header)
+	}
+}
+
+// WithWebSocketDialer returns a WebSocketClientOption configured with the websocket.Dialer.
+func WithWebSocketDialer(dialer *websocket.Dialer) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.dialer = dialer
+	}
+}
+
+// WithOnConnectionLost returns a WebSocketClientOption configured with the callback of an unexpected disconnection.
+func WithOnConnectionLost(onConnectionLost func(err error)) WebSocketClientOption {
+	return func(c *WebSocketClient) {
+		c.onConnectionLost = onConnectionLost
+	}
+}
+
+// NewWebSocketClient returns a WebSocketClient instance.
+func NewWebSocketClient(endpoint string, opts ...WebSocketClientOption) *WebSocketClient {
+	c := &WebSocketClient{
+		endpoint:             endpoint,
+		header:               http.Header{},
+		connectionAckTimeout: 10 * time.Second,
+		dialer:               websocket.DefaultDialer,
+		onConnectionLost:     func(error) {},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect opens the websocket connection and waits for connection_ack.
+// It returns ErrWebSocketConnected if the connection is already open.
+func (c *WebSocketClient) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	connected := c.ws != nil
+	c.mu.Unlock()
+	if connected {
+		return ErrWebSocketConnected
+	}
+
+	header := c.header.Clone()
+	header.Set("Sec-WebSocket-Protocol", GraphQLTransportWSProtocol)
+	ws, _, err := c.dialer.DialContext(ctx, c.endpoint, header)
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to connect to websocket", "error", err)
+		return err
+	}
+
+	init := wsMessage{Type: "connection_init"}
+	if c.connectionParams != nil {
+		if init.Payload, err = json.Marshal(c.connectionParams); err != nil {
+			_ = ws.Close()
+			return err
+		}
+	}
+	if err := ws.WriteJSON(init); err != nil {
+		_ = ws.Close()
+		return err
+	}
+	deadline := time.Now().Add(c.connectionAckTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := ws.SetReadDeadline(deadline); err != nil {
+		_ = ws.Close()
+		return err
+	}
+	for {
+		msg := wsMessage{}
+		if err := ws.ReadJSON(&msg); err != nil {
+			_ = ws.Close()
+			return fmt.Errorf("unable to receive connection_ack: %w", err)
+		}
+		if msg.Type == "connection_ack" {
+			break
+		}
+		if msg.Type == "ping" {
+			if err := ws.WriteJSON(wsMessage{Type: "pong"}); err != nil {
+				_ = ws.Close()
+				return err
+			}
+			continue
+		}
+		_ = ws.Close()
+		return fmt.Errorf("unexpected message %q before connection_ack", msg.Type)
+	}
+	if err := ws.SetReadDeadline(time.Time{}); err != nil {
+		_ = ws.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	if c.ws != nil {
+		// Another Connect won meanwhile.
+		c.mu.Unlock()
+		_ = ws.Close()
+		return ErrWebSocketConnected
+	}
+	c.ws = ws
+	c.subscriptions = map[string]*WebSocketSubscription{}
+	c.closing = false
+	c.done = make(chan struct{})
+	done := c.done
+	c.mu.Unlock()
+
+	go c.readLoop(ws, done)
+	if c.pingInterval > 0 {
+		go c.pingLoop(done)
+	}
+	return nil
+}
+
+// Subscribe starts the operation, typically a subscription, and calls onReceive for each result.
+func (c *WebSocketClient) Subscribe(request PostRequest, onReceive func(response *Response)) (*WebSocketSubscription, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		slog.Error("unable to marshal request", "error", err, "request", request)
+		return nil, err
+	}
+	s := &WebSocketSubscription{
+		ID:        uuid.New().String(),
+		client:    c,
+		onReceive: onReceive,
+		done:      make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	if c.ws == nil || c.closing {
+		c.mu.Unlock()
+		return nil, ErrWebSocketClosed
+	}
+	c.subscriptions[s.ID] = s
+	c.mu.Unlock()
+
+	if err := c.write(wsMessage{ID: s.ID, Type: "subscribe", Payload: payload}); err != nil {
+		c.remove(s.ID)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close completes the running operations and closes the websocket connection.
+func (c *WebSocketClient) Close() error {
+	c.mu.Lock()
+	ws, done := c.ws, c.done
+	if ws == nil || c.closing {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closing = true
+	c.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	c.writeMu.Lock()
+	err := ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	if err != nil {
+		slog.Warn("unable to write close message", "error", err)
+	}
+	if err := ws.Close(); err != nil {
+		slog.Error("unable to close websocket", "error", err)
+		return err
+	}
+	<-done
+	return nil
+}
+
+func (c *WebSocketClient) write(msg wsMessage) error {
+	c.mu.Lock()
+	ws := c.ws
+	c.mu.Unlock()
+	if ws == nil {
+		return ErrWebSocketClosed
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return ws.WriteJSON(msg)
+}
+
+func (c *WebSocketClient) remove(id string) (*WebSocketSubscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	return s, ok
+}
+
+func (c *WebSocketClient) subscription(id string) (*WebSocketSubscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.subscriptions[id]
+	return s, ok
+}
+
+func (c *WebSocketClient) readLoop(ws *websocket.Conn, done chan struct{}) {
+	var lost error
+	defer func() {
+		c.mu.Lock()
+		var subscriptions map[string]*WebSocketSubscription
+		closing := c.closing
+		// The client may already be connected again with another websocket.
+		if c.ws == ws {
+			subscriptions = c.subscriptions
+			c.subscriptions = map[string]*WebSocketSubscription{}
+			c.ws = nil
+		}
+		c.mu.Unlock()
+		for _, s := range subscriptions {
+			s.finish(ErrWebSocketClosed)
+		}
+		close(done)
+		if !closing {
+			c.onConnectionLost(lost)
+		}
+	}()
+
+	for {
+		msg := wsMessage{}
+		if err := ws.ReadJSON(&msg); err != nil {
+			lost = err
+			return
+		}
+		switch msg.Type {
+		case "ping":
+			if err := c.write(wsMessage{Type: "pong"}); err != nil {
+				slog.Warn("unable to write pong", "error", err)
+			}
+		case "pong":
+		case "next":
+			s, ok := c.subscription(msg.ID)
+			if !ok {
+				continue
+			}
+			response := new(Response)
+			if err := json.Unmarshal(msg.Payload, response); err != nil {
+				slog.Error("unable to decode next", "error", err, "payload", string(msg.Payload))
+				continue
+			}
+			s.onReceive(response)
+		case "error":
+			s, ok := c.remove(msg.ID)
+			if !ok {
+				continue
+			}
+			errs := []interface{}{}
+			if err := json.Unmarshal(msg.Payload, &errs); err != nil {
+				slog.Error("unable to decode error", "error", err, "payload", string(msg.Payload))
+			}
+			s.onReceive(&Response{Errors: &errs})
+			s.finish(fmt.Errorf("operation failed: %s", string(msg.Payload)))
+		case "complete":
+			if s, ok := c.remove(msg.ID); ok {
+				s.finish(nil)
+			}
+		default:
+			slog.Warn("unknown message received", "type", msg.Type)
+		}
+	}
+}
+
+func (c *WebSocketClient) pingLoop(done chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.write(wsMessage{Type: "ping"}); err != nil {
+				slog.Warn("unable to write ping", "error", err)
+			}
+		}
+	}
+}
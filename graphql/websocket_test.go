@@ -0,0 +1,300 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type transportWSServer struct {
+	*httptest.Server
+
+	ackDelay time.Duration
+
+	mu        sync.Mutex
+	init      json.RawMessage
+	pongs     int
+	completed []string
+	conns     []*websocket.Conn
+}
+
+func newTransportWSServer(ackDelay time.Duration) *transportWSServer {
+	s := &transportWSServer{ackDelay: ackDelay}
+	upgrader := websocket.Upgrader{Subprotocols: []string{GraphQLTransportWSProtocol}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, ws)
+		s.mu.Unlock()
+		go s.serve(ws)
+	}))
+	return s
+}
+
+func (s *transportWSServer) serve(ws *websocket.Conn) {
+	defer func() { _ = ws.Close() }()
+	var mu sync.Mutex
+	write := func(msg wsMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = ws.WriteJSON(msg)
+	}
+	for {
+		msg := wsMessage{}
+		if err := ws.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "connection_init":
+			s.mu.Lock()
+			s.init = msg.Payload
+			s.mu.Unlock()
+			time.Sleep(s.ackDelay)
+			write(wsMessage{Type: "ping"})
+			write(wsMessage{Type: "connection_ack"})
+		case "ping":
+			write(wsMessage{Type: "pong"})
+		case "pong":
+			s.mu.Lock()
+			s.pongs++
+			s.mu.Unlock()
+		case "subscribe":
+			request := PostRequest{}
+			if err := json.Unmarshal(msg.Payload, &request); err != nil {
+				return
+			}
+			switch {
+			case strings.Contains(request.Query, "invalid"):
+				write(wsMessage{ID: msg.ID, Type: "error", Payload: json.RawMessage(`[{"message":"invalid query"}]`)})
+			case strings.Contains(request.Query, "finite"):
+				write(wsMessage{ID: msg.ID, Type: "next", Payload: json.RawMessage(`{"data":{"count":1}}`)})
+				write(wsMessage{ID: msg.ID, Type: "next", Payload: json.RawMessage(`{"data":{"count":2}}`)})
+				write(wsMessage{ID: msg.ID, Type: "complete"})
+			default:
+				write(wsMessage{ID: msg.ID, Type: "next", Payload: json.RawMessage(`{"data":{"count":1}}`)})
+			}
+		case "complete":
+			s.mu.Lock()
+			s.completed = append(s.completed, msg.ID)
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *transportWSServer) url() string {
+	return strings.Replace(s.URL, "http", "ws", 1)
+}
+
+func TestWebSocketClient_Subscribe(t *testing.T) {
+	s := newTransportWSServer(0)
+	defer s.Close()
+
+	c := NewWebSocketClient(s.url(), WithConnectionParams(map[string]string{"token": "xxx"}))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	s.mu.Lock()
+	init := string(s.init)
+	s.mu.Unlock()
+	if init != `{"token":"xxx"}` {
+		t.Errorf("connection_init payload: %s", init)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "next and complete", query: "subscription finite { count }", want: []float64{1, 2}},
+		{name: "error", query: "subscription invalid { count }", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []float64
+			sub, err := c.Subscribe(PostRequest{Query: tt.query}, func(r *Response) {
+				mu.Lock()
+				defer mu.Unlock()
+				if r.Errors != nil {
+					return
+				}
+				got = append(got, r.Data.(map[string]interface{})["count"].(float64))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-sub.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out")
+			}
+			if err := sub.Err(); (err != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, wantErr %v", err, tt.wantErr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(got) != len(tt.want) {
+				t.Fatalf("want: %v, got: %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("want: %v, got: %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_Stop(t *testing.T) {
+	s := newTransportWSServer(0)
+	defer s.Close()
+
+	c := NewWebSocketClient(s.url())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	ch := make(chan *Response, 1)
+	sub, err := c.Subscribe(PostRequest{Query: "subscription { count }"}, func(r *Response) { ch <- r })
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	sub.Stop()
+	sub.Stop()
+	if err := sub.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		completed := append([]string{}, s.completed...)
+		s.mu.Unlock()
+		if len(completed) == 1 && completed[0] == sub.ID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("completed: %v", completed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketClient_PingPong(t *testing.T) {
+	s := newTransportWSServer(0)
+	defer s.Close()
+
+	c := NewWebSocketClient(s.url(), WithPingInterval(10*time.Millisecond))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	// The server pings before connection_ack, and answers the client pings.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		pongs := s.pongs
+		s.mu.Unlock()
+		if pongs > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no pong received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketClient_ConnectionAckTimeout(t *testing.T) {
+	s := newTransportWSServer(time.Second)
+	defer s.Close()
+
+	c := NewWebSocketClient(s.url(), WithConnectionAckTimeout(100*time.Millisecond))
+	if err := c.Connect(context.Background()); err == nil {
+		t.Fatal("Connect() should fail")
+	}
+	if _, err := c.Subscribe(PostRequest{Query: "subscription { count }"}, func(*Response) {}); err != ErrWebSocketClosed {
+		t.Errorf("Subscribe() error = %v", err)
+	}
+}
+
+func TestWebSocketClient_ConnectionLost(t *testing.T) {
+	s := newTransportWSServer(0)
+	defer s.Close()
+
+	lost := make(chan error, 1)
+	c := NewWebSocketClient(s.url(), WithOnConnectionLost(func(err error) { lost <- err }))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := c.Subscribe(PostRequest{Query: "subscription { count }"}, func(*Response) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	for _, ws := range s.conns {
+		_ = ws.Close()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-lost:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	if err := sub.Err(); err != ErrWebSocketClosed {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWebSocketClient_Reconnect(t *testing.T) {
+	s := newTransportWSServer(0)
+	defer s.Close()
+
+	c := NewWebSocketClient(s.url())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(context.Background()); err != ErrWebSocketConnected {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first read loop must not reset the second connection.
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+	ch := make(chan *Response, 1)
+	if _, err := c.Subscribe(PostRequest{Query: "subscription { count }"}, func(r *Response) { ch <- r }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -29,3 +29,7 @@ func (d *graphQLClient) Post(header http.Header, request graphql.PostRequest) (*
 func (d *graphQLClient) PostAsync(header http.Header, request graphql.PostRequest, callback func(*graphql.Response, error)) (context.CancelFunc, error) {
 	return d.client.PostAsync(header, request, callback)
 }
+
+func (d *graphQLClient) UploadPresigned(ctx context.Context, target graphql.PresignedUpload, file graphql.Upload) error {
+	return d.client.UploadPresigned(ctx, target, file)
+}
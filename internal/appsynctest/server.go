@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 
 	"github.com/eclipse/paho.mqtt.golang/packets"
 	"github.com/google/uuid"
@@ -71,8 +72,8 @@ type Subscription {
 
 type mqttPublisher struct {
 	w                http.ResponseWriter
-	mqttSessions     mqttSessions
-	grapqhWsSessions grapqhWsSessions
+	mqttSessions     *mqttSessions
+	grapqhWsSessions *grapqhWsSessions
 }
 
 func (m *mqttPublisher) Header() http.Header {
@@ -80,39 +81,8 @@ func (m *mqttPublisher) Header() http.Header {
 }
 
 func (m *mqttPublisher) Write(payload []byte) (int, error) {
-	if len(m.mqttSessions) != 0 {
-		go func() {
-			pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
-			pub.TopicName = mqttEchoTopic
-			pub.Payload = payload
-			for s := range m.mqttSessions {
-				writer, err := s.NextWriter(websocket.BinaryMessage)
-				if err != nil {
-					slog.Error("unable to get next writer", "error", err)
-					continue
-				}
-				if err := pub.Write(writer); err != nil {
-					slog.Error("unable to write packet", "error", err)
-					continue
-				}
-				if err := writer.Close(); err != nil {
-					slog.Error("unable to close writer", "error", err)
-					continue
-				}
-			}
-		}()
-	}
-	if len(m.grapqhWsSessions) != 0 {
-		go func() {
-			for s := range m.grapqhWsSessions {
-				data := json.RawMessage(fmt.Sprintf(gqlwsdatafmt, "id", string(payload)))
-				if err := s.WriteJSON(data); err != nil {
-					slog.Warn("unable to write json", "error", err)
-					continue
-				}
-			}
-		}()
-	}
+	go m.mqttSessions.publish(payload)
+	go m.grapqhWsSessions.publish(payload)
 	return m.w.Write(payload)
 }
 
@@ -137,8 +107,58 @@ func (e *echoResolver) SubscribeToEcho() string {
 	return e.message
 }
 
-func mqttWsSession(ws *websocket.Conn, onConnected func(ws *websocket.Conn), onDisconnected func(ws *websocket.Conn)) {
+type mqttSession struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (m *mqttSession) writePacket(mt int, cp packets.ControlPacket) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	writer, err := m.ws.NextWriter(mt)
+	if err != nil {
+		return fmt.Errorf("unable to get next writer: %w", err)
+	}
+	if err := cp.Write(writer); err != nil {
+		return fmt.Errorf("unable to write packet: %w", err)
+	}
+	return writer.Close()
+}
+
+type mqttSessions struct {
+	mu       sync.Mutex
+	sessions map[*mqttSession]bool
+}
+
+func (m *mqttSessions) add(s *mqttSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s] = true
+}
+
+func (m *mqttSessions) remove(s *mqttSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, s)
+}
+
+func (m *mqttSessions) publish(payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for s := range m.sessions {
+		pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+		pub.TopicName = mqttEchoTopic
+		pub.Payload = payload
+		if err := s.writePacket(websocket.BinaryMessage, pub); err != nil {
+			slog.Error("unable to publish", "error", err)
+		}
+	}
+}
+
+func mqttWsSession(ws *websocket.Conn, sessions *mqttSessions) {
+	session := &mqttSession{ws: ws}
 	defer func() {
+		sessions.remove(session)
 		if err := ws.Close(); err != nil {
 			slog.Error("unable to close websocket", "error", err)
 		}
@@ -161,7 +181,7 @@ func mqttWsSession(ws *websocket.Conn, onConnected func(ws *websocket.Conn), onD
 		switch cp.(type) {
 		case *packets.ConnectPacket:
 			ack = packets.NewControlPacket(packets.Connack)
-			onConnected(ws)
+			sessions.add(session)
 		case *packets.SubscribePacket:
 			ack = packets.NewControlPacket(packets.Suback)
 			ack.(*packets.SubackPacket).MessageID = cp.(*packets.SubscribePacket).MessageID
@@ -169,31 +189,82 @@ func mqttWsSession(ws *websocket.Conn, onConnected func(ws *websocket.Conn), onD
 			ack = packets.NewControlPacket(packets.Unsuback)
 			ack.(*packets.UnsubackPacket).MessageID = cp.(*packets.UnsubscribePacket).MessageID
 		case *packets.DisconnectPacket:
-			onDisconnected(ws)
 			return
 		}
 		if ack == nil {
 			continue
 		}
 
-		writer, err := ws.NextWriter(mt)
-		if err != nil {
-			slog.Error("unable to get next writer", "error", err)
-			return
-		}
-		if err := ack.Write(writer); err != nil {
-			slog.Error("unable to write packet", "error", err)
+		if err := session.writePacket(mt, ack); err != nil {
+			slog.Error("unable to write ack", "error", err)
 			return
 		}
-		if err := writer.Close(); err != nil {
-			slog.Error("unable to close writer", "error", err)
-			return
+	}
+}
+
+type grapqhWsSession struct {
+	mu  sync.Mutex
+	ws  *websocket.Conn
+	ids map[string]bool
+}
+
+func (g *grapqhWsSession) writeJSON(v interface{}) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.ws.WriteJSON(v)
+}
+
+func (g *grapqhWsSession) publish(payload []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id := range g.ids {
+		data := json.RawMessage(fmt.Sprintf(gqlwsdatafmt, id, string(payload)))
+		if err := g.ws.WriteJSON(data); err != nil {
+			slog.Warn("unable to write json", "error", err)
 		}
 	}
 }
 
-func graphQLWsSession(ws *websocket.Conn, onConnected func(ws *websocket.Conn), onDisconnected func(ws *websocket.Conn)) {
+func (g *grapqhWsSession) register(id string, started bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if started {
+		g.ids[id] = true
+	} else {
+		delete(g.ids, id)
+	}
+}
+
+type grapqhWsSessions struct {
+	mu       sync.Mutex
+	sessions map[*grapqhWsSession]bool
+}
+
+func (g *grapqhWsSessions) add(s *grapqhWsSession) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sessions[s] = true
+}
+
+func (g *grapqhWsSessions) remove(s *grapqhWsSession) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.sessions, s)
+}
+
+func (g *grapqhWsSessions) publish(payload []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for s := range g.sessions {
+		s.publish(payload)
+	}
+}
+
+func graphQLWsSession(ws *websocket.Conn, sessions *grapqhWsSessions) {
+	session := &grapqhWsSession{ws: ws, ids: map[string]bool{}}
+	sessions.add(session)
 	defer func() {
+		sessions.remove(session)
 		if err := ws.Close(); err != nil {
 			slog.Error("unable to close websocket", "error", err)
 		}
@@ -209,14 +280,14 @@ func graphQLWsSession(ws *websocket.Conn, onConnected func(ws *websocket.Conn),
 		switch msg["type"].(string) {
 		case "connection_init":
 			ack = json.RawMessage(gqlwsconnack)
-			onConnected(ws)
 		case "start":
 			ack = json.RawMessage(fmt.Sprintf(gqlwsstartackfmt, msg["id"].(string)))
+			session.register(msg["id"].(string), true)
 		case "stop":
 			ack = json.RawMessage(fmt.Sprintf(gqlwscompletefmt, msg["id"].(string)))
-			onDisconnected(ws)
+			session.register(msg["id"].(string), false)
 		}
-		if err := ws.WriteJSON(ack); err != nil {
+		if err := session.writeJSON(ack); err != nil {
 			slog.Error("unable to write json", "error", err)
 			return
 		}
@@ -229,7 +300,7 @@ func newQueryHandlerFunc(h relay.Handler) http.HandlerFunc {
 	}
 }
 
-func newMutationHandlerFunc(h relay.Handler, mqtt mqttSessions, graphqlws grapqhWsSessions) http.HandlerFunc {
+func newMutationHandlerFunc(h relay.Handler, mqtt *mqttSessions, graphqlws *grapqhWsSessions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		h.ServeHTTP(&mqttPublisher{w, mqtt, graphqlws}, r)
 	}
@@ -282,7 +353,7 @@ func isGraphQLWs(r *http.Request) bool {
 		r.Header.Get("Sec-Websocket-Protocol") == "graphql-ws"
 }
 
-func newMqttWsHandlerFunc(onConnected func(ws *websocket.Conn), onDisconnected func(ws *websocket.Conn)) http.HandlerFunc {
+func newMqttWsHandlerFunc(sessions *mqttSessions) http.HandlerFunc {
 	upgrader := websocket.Upgrader{}
 	return func(w http.ResponseWriter, r *http.Request) {
 		ws, err := upgrader.Upgrade(w, r, nil)
@@ -290,14 +361,11 @@ func newMqttWsHandlerFunc(onConnected func(ws *websocket.Conn), onDisconnected f
 			slog.Warn("unable to upgrade websocket", "error", err)
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
-		go mqttWsSession(ws, onConnected, onDisconnected)
+		go mqttWsSession(ws, sessions)
 	}
 }
 
-type mqttSessions map[*websocket.Conn]bool
-type grapqhWsSessions map[*websocket.Conn]bool
-
-func newGraphQLWsHandlerFunc(onConnected func(ws *websocket.Conn), onDisconnected func(ws *websocket.Conn)) http.HandlerFunc {
+func newGraphQLWsHandlerFunc(sessions *grapqhWsSessions) http.HandlerFunc {
 	upgrader := websocket.Upgrader{}
 	return func(w http.ResponseWriter, r *http.Request) {
 		ws, err := upgrader.Upgrade(w, r, nil)
@@ -305,26 +373,20 @@ func newGraphQLWsHandlerFunc(onConnected func(ws *websocket.Conn), onDisconnecte
 			slog.Warn("unable to upgrade websocket", "error", err)
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
-		go graphQLWsSession(ws, onConnected, onDisconnected)
+		go graphQLWsSession(ws, sessions)
 	}
 }
 
 func newAppSyncEchoHandlerFunc(initialMessage string) http.HandlerFunc {
 	s := graphqlgo.MustParseSchema(schema, &echoResolver{initialMessage})
 	handler := relay.Handler{Schema: s}
-	mqttSessions := make(mqttSessions)
-	grapqhWsSessions := make(grapqhWsSessions)
+	mqttSessions := &mqttSessions{sessions: map[*mqttSession]bool{}}
+	grapqhWsSessions := &grapqhWsSessions{sessions: map[*grapqhWsSession]bool{}}
 	query := newQueryHandlerFunc(handler)
 	mutation := newMutationHandlerFunc(handler, mqttSessions, grapqhWsSessions)
 	subscription := newSubscriptionHandlerFunc()
-	mqttws := newMqttWsHandlerFunc(
-		func(ws *websocket.Conn) { mqttSessions[ws] = true },
-		func(ws *websocket.Conn) { delete(mqttSessions, ws) },
-	)
-	graphqlws := newGraphQLWsHandlerFunc(
-		func(ws *websocket.Conn) { grapqhWsSessions[ws] = true },
-		func(ws *websocket.Conn) { delete(grapqhWsSessions, ws) },
-	)
+	mqttws := newMqttWsHandlerFunc(mqttSessions)
+	graphqlws := newGraphQLWsHandlerFunc(grapqhWsSessions)
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
@@ -0,0 +1,97 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gorilla/websocket"
+)
+
+// mqttWebsocketOpener returns a paho connection function which dials the MQTT websockets with dialer,
+// since paho only uses its dialer for plain TCP connections.
+func mqttWebsocketOpener(dialer *net.Dialer, proxy func(*http.Request) (*url.URL, error)) MQTT.OpenConnectionFunc {
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	return func(uri *url.URL, options MQTT.ClientOptions) (net.Conn, error) {
+		if uri.Scheme != "ws" && uri.Scheme != "wss" {
+			return nil, errors.New("mqtt dialer only supports websocket brokers")
+		}
+		dialURI := *uri
+		dialURI.User = nil
+		d := &websocket.Dialer{
+			NetDialContext:   dialer.DialContext,
+			Proxy:            proxy,
+			HandshakeTimeout: options.ConnectTimeout,
+			Subprotocols:     []string{"mqtt"},
+		}
+		if uri.Scheme == "wss" {
+			d.TLSClientConfig = options.TLSConfig
+		}
+		ctx := context.Background()
+		if options.ConnectTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+			defer cancel()
+		}
+		ws, _, err := d.DialContext(ctx, dialURI.String(), options.HTTPHeaders)
+		if err != nil {
+			return nil, err
+		}
+		return &mqttWebsocketConn{Conn: ws}, nil
+	}
+}
+
+// mqttWebsocketConn adapts a websocket to the net.Conn paho reads and writes MQTT packets on.
+type mqttWebsocketConn struct {
+	*websocket.Conn
+	rmu sync.Mutex
+	r   io.Reader
+	wmu sync.Mutex
+}
+
+func (c *mqttWebsocketConn) Read(p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	for {
+		if c.r == nil {
+			_, r, err := c.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.r = r
+		}
+		n, err := c.r.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.r = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *mqttWebsocketConn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *mqttWebsocketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
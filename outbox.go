@@ -0,0 +1,201 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// OutboxItem represents a mutation waiting in an Outbox.
+// Key is local to the Outbox and is not sent to the server.
+type OutboxItem struct {
+	Key        string              `json:"key"`
+	Request    graphql.PostRequest `json:"request"`
+	Attempts   int                 `json:"attempts"`
+	EnqueuedAt time.Time           `json:"enqueuedAt"`
+}
+
+// OutboxStore persists the items of an Outbox.
+type OutboxStore interface {
+	// Put inserts the item, or replaces the item with the same key in place.
+	Put(item OutboxItem) error
+	// Delete removes the item with the given key.
+	Delete(key string) error
+	// List returns the items in insertion order.
+	List() ([]OutboxItem, error)
+}
+
+// OutboxOption represents options for an Outbox.
+type OutboxOption func(*Outbox)
+
+// WithOutboxMaxElapsedTime returns an OutboxOption configured with the maximum time spent retrying a single item during a replay.
+func WithOutboxMaxElapsedTime(maxElapsedTime time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.maxElapsedTime = maxElapsedTime
+	}
+}
+
+// WithOnDelivered returns an OutboxOption configured with the callback for delivered items.
+func WithOnDelivered(onDelivered func(item OutboxItem, response *graphql.Response)) OutboxOption {
+	return func(o *Outbox) {
+		o.onDelivered = onDelivered
+	}
+}
+
+// WithOnFailed returns an OutboxOption configured with the callback for items rejected by the server.
+func WithOnFailed(onFailed func(item OutboxItem, err error)) OutboxOption {
+	return func(o *Outbox) {
+		o.onFailed = onFailed
+	}
+}
+
+// WithOnConflicted returns an OutboxOption configured with the callback for items rejected because of a version conflict.
+func WithOnConflicted(onConflicted func(item OutboxItem, response *graphql.Response)) OutboxOption {
+	return func(o *Outbox) {
+		o.onConflicted = onConflicted
+	}
+}
+
+// Outbox queues mutations and replays them in order once they can be delivered.
+type Outbox struct {
+	client         *Client
+	store          OutboxStore
+	maxElapsedTime time.Duration
+	onDelivered    func(OutboxItem, *graphql.Response)
+	onFailed       func(OutboxItem, error)
+	onConflicted   func(OutboxItem, *graphql.Response)
+
+	mu sync.Mutex
+	// enqueueMu makes the idempotency check and the insertion of Enqueue atomic.
+	enqueueMu sync.Mutex
+}
+
+// NewOutbox returns an Outbox instance.
+func NewOutbox(client *Client, store OutboxStore, opts ...OutboxOption) *Outbox {
+	o := &Outbox{
+		client:         client,
+		store:          store,
+		maxElapsedTime: 1 * time.Minute,
+		onDelivered:    func(OutboxItem, *graphql.Response) {},
+		onFailed:       func(OutboxItem, error) {},
+		onConflicted:   func(OutboxItem, *graphql.Response) {},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Enqueue adds a mutation with the given idempotency key.
+// Enqueueing a key that is already queued is a no-op.
+//
+// The key only deduplicates the queue and is not sent to the server. A mutation delivered just before a crash,
+// but not yet removed from the store, is replayed again, so mutations which must not be applied twice
+// should carry an idempotency key of their own in their input, such as a client generated ID.
+func (o *Outbox) Enqueue(key string, request graphql.PostRequest) error {
+	if !request.IsMutation() {
+		return errors.New("only mutations can be enqueued")
+	}
+	o.enqueueMu.Lock()
+	defer o.enqueueMu.Unlock()
+	items, err := o.store.List()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Key == key {
+			slog.Debug("item already enqueued", "key", key)
+			return nil
+		}
+	}
+	return o.store.Put(OutboxItem{Key: key, Request: request, EnqueuedAt: time.Now()})
+}
+
+// Len returns the number of queued items.
+func (o *Outbox) Len() (int, error) {
+	items, err := o.store.List()
+	if err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// Replay posts the queued items in order.
+// It stops at the first item that cannot be delivered because of a transient error and returns that error,
+// leaving the item and the following ones queued for the next replay.
+func (o *Outbox) Replay(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	items, err := o.store.List()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		response, err := o.post(ctx, &item)
+		if err != nil {
+			slog.Warn("unable to deliver outbox item", "error", err, "key", item.Key)
+			if perr := o.store.Put(item); perr != nil {
+				slog.Error("unable to update outbox item", "error", perr, "key", item.Key)
+			}
+			return err
+		}
+		if err := o.store.Delete(item.Key); err != nil {
+			return err
+		}
+		_, conflicted := NewConflictError(response)
+		switch {
+		case conflicted:
+			o.onConflicted(item, response)
+		case response.Errors != nil && len(*response.Errors) > 0:
+			o.onFailed(item, fmt.Errorf("mutation failed: %v", *response.Errors))
+		default:
+			o.onDelivered(item, response)
+		}
+	}
+	return nil
+}
+
+// Run replays the queued items every interval until ctx is done.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := o.Replay(ctx); err != nil {
+			slog.Debug("outbox replay incomplete", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *Outbox) post(ctx context.Context, item *OutboxItem) (*graphql.Response, error) {
+	op := func() (*graphql.Response, error) {
+		item.Attempts++
+		response, err := o.client.Post(item.Request)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != nil && isTransientStatus(*response.StatusCode) {
+			return nil, fmt.Errorf("transient status: %s", http.StatusText(*response.StatusCode))
+		}
+		return response, nil
+	}
+	return backoff.Retry(ctx, op,
+		backoff.WithBackOff(backoff.NewExponentialBackOff()),
+		backoff.WithMaxElapsedTime(o.maxElapsedTime))
+}
+
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
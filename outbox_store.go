@@ -0,0 +1,137 @@
+package appsync
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type memoryOutboxStore struct {
+	mu    sync.Mutex
+	items []OutboxItem
+}
+
+// NewMemoryOutboxStore returns an OutboxStore keeping the items in memory.
+func NewMemoryOutboxStore() OutboxStore {
+	return &memoryOutboxStore{}
+}
+
+func (m *memoryOutboxStore) Put(item OutboxItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = putOutboxItem(m.items, item)
+	return nil
+}
+
+func (m *memoryOutboxStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = removeOutboxItem(m.items, key)
+	return nil
+}
+
+func (m *memoryOutboxStore) List() ([]OutboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]OutboxItem(nil), m.items...), nil
+}
+
+type fileOutboxStore struct {
+	mu    sync.Mutex
+	path  string
+	items []OutboxItem
+}
+
+// NewFileOutboxStore returns an OutboxStore persisting the items as JSON to the file at path.
+// Items already persisted at path are loaded.
+func NewFileOutboxStore(path string) (OutboxStore, error) {
+	f := &fileOutboxStore{path: path}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return f, nil
+	case err != nil:
+		slog.Error("unable to read outbox file", "error", err, "path", path)
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &f.items); err != nil {
+		slog.Error("unable to unmarshal outbox file", "error", err, "path", path)
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *fileOutboxStore) Put(item OutboxItem) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.save(putOutboxItem(append([]OutboxItem(nil), f.items...), item))
+}
+
+func (f *fileOutboxStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.save(removeOutboxItem(append([]OutboxItem(nil), f.items...), key))
+}
+
+func (f *fileOutboxStore) List() ([]OutboxItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]OutboxItem(nil), f.items...), nil
+}
+
+// save writes the items to a temporary file and renames it over the outbox file.
+func (f *fileOutboxStore) save(items []OutboxItem) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		slog.Error("unable to marshal outbox items", "error", err)
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		slog.Error("unable to create temporary outbox file", "error", err, "path", f.path)
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		slog.Error("unable to rename outbox file", "error", err, "path", f.path)
+		return err
+	}
+	f.items = items
+	return nil
+}
+
+func putOutboxItem(items []OutboxItem, item OutboxItem) []OutboxItem {
+	for i := range items {
+		if items[i].Key == item.Key {
+			items[i] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+func removeOutboxItem(items []OutboxItem, key string) []OutboxItem {
+	for i := range items {
+		if items[i].Key == key {
+			return append(items[:i], items[i+1:]...)
+		}
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package appsync
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func TestOutboxStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	file, err := NewFileOutboxStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		store OutboxStore
+	}{
+		{name: "memory", store: NewMemoryOutboxStore()},
+		{name: "file", store: file},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"1", "2", "3"} {
+				if err := tt.store.Put(OutboxItem{Key: key, Request: graphql.PostRequest{Query: "mutation " + key}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tt.store.Put(OutboxItem{Key: "2", Attempts: 1}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.store.Delete("1"); err != nil {
+				t.Fatal(err)
+			}
+			items, err := tt.store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 2 || items[0].Key != "2" || items[0].Attempts != 1 || items[1].Key != "3" {
+				t.Fatal(items)
+			}
+		})
+	}
+
+	reopened, err := NewFileOutboxStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[1].Request.Query != "mutation 3" {
+		t.Fatal(items)
+	}
+}
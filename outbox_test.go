@@ -0,0 +1,152 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+type scriptedGraphQLAPI struct {
+	mu        sync.Mutex
+	responses []*graphql.Response
+	errs      []error
+	requests  []graphql.PostRequest
+}
+
+func (s *scriptedGraphQLAPI) Post(header http.Header, request graphql.PostRequest) (*graphql.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request)
+	if len(s.responses) == 0 {
+		return nil, errors.New("offline")
+	}
+	r, err := s.responses[0], s.errs[0]
+	s.responses, s.errs = s.responses[1:], s.errs[1:]
+	return r, err
+}
+
+func (s *scriptedGraphQLAPI) PostAsync(header http.Header, request graphql.PostRequest, callback func(*graphql.Response, error)) (context.CancelFunc, error) {
+	go callback(s.Post(header, request))
+	return func() {}, nil
+}
+
+func (s *scriptedGraphQLAPI) push(r *graphql.Response, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, r)
+	s.errs = append(s.errs, err)
+}
+
+func TestOutbox_Enqueue(t *testing.T) {
+	o := NewOutbox(NewClient(&scriptedGraphQLAPI{}), NewMemoryOutboxStore())
+	if err := o.Enqueue("key", graphql.PostRequest{Query: "query "}); err == nil {
+		t.Fatal("query must not be enqueued")
+	}
+	for i := 0; i < 2; i++ {
+		if err := o.Enqueue("key", graphql.PostRequest{Query: "mutation "}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := o.Len(); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestOutbox_EnqueueConcurrently(t *testing.T) {
+	o := NewOutbox(NewClient(&scriptedGraphQLAPI{}), NewMemoryOutboxStore())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := o.Enqueue("key", graphql.PostRequest{Query: "mutation "}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, err := o.Len(); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestOutbox_Replay(t *testing.T) {
+	statusOK := http.StatusOK
+	unavailable := http.StatusServiceUnavailable
+	failure := []interface{}{map[string]interface{}{"errorType": "ValidationError"}}
+	conflict := []interface{}{map[string]interface{}{"errorType": "ConflictUnhandled"}}
+
+	api := &scriptedGraphQLAPI{}
+	api.push(&graphql.Response{StatusCode: &statusOK, Data: "a"}, nil)
+	api.push(nil, errors.New("offline"))
+	api.push(&graphql.Response{StatusCode: &unavailable}, nil)
+	api.push(&graphql.Response{StatusCode: &statusOK, Data: "b"}, nil)
+	api.push(&graphql.Response{StatusCode: &statusOK, Errors: &failure}, nil)
+	api.push(&graphql.Response{StatusCode: &statusOK, Errors: &conflict}, nil)
+
+	var delivered, failed, conflicted []string
+	o := NewOutbox(NewClient(api), NewMemoryOutboxStore(),
+		WithOutboxMaxElapsedTime(3*time.Second),
+		WithOnDelivered(func(item OutboxItem, r *graphql.Response) { delivered = append(delivered, item.Key) }),
+		WithOnFailed(func(item OutboxItem, err error) { failed = append(failed, item.Key) }),
+		WithOnConflicted(func(item OutboxItem, r *graphql.Response) { conflicted = append(conflicted, item.Key) }),
+	)
+	for _, key := range []string{"1", "2", "3", "4"} {
+		if err := o.Enqueue(key, graphql.PostRequest{Query: "mutation " + key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := o.Replay(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := o.Len(); err != nil || n != 0 {
+		t.Fatal(n, err)
+	}
+	if !reflect.DeepEqual(delivered, []string{"1", "2"}) {
+		t.Fatal(delivered)
+	}
+	if !reflect.DeepEqual(failed, []string{"3"}) {
+		t.Fatal(failed)
+	}
+	if !reflect.DeepEqual(conflicted, []string{"4"}) {
+		t.Fatal(conflicted)
+	}
+	for i, want := range []string{"1", "2", "2", "2", "3", "4"} {
+		if api.requests[i].Query != "mutation "+want {
+			t.Fatal(api.requests)
+		}
+	}
+}
+
+func TestOutbox_ReplayStopsOnTransientError(t *testing.T) {
+	store := NewMemoryOutboxStore()
+	o := NewOutbox(NewClient(&scriptedGraphQLAPI{}), store, WithOutboxMaxElapsedTime(time.Millisecond))
+	for _, key := range []string{"1", "2"} {
+		if err := o.Enqueue(key, graphql.PostRequest{Query: "mutation "}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Replay(context.Background()); err == nil {
+		t.Fatal("replay must fail when offline")
+	}
+	items, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Key != "1" || items[0].Attempts == 0 {
+		t.Fatal(items)
+	}
+}
+
+func (s *scriptedGraphQLAPI) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.responses)
+}
@@ -2,10 +2,8 @@ package appsync
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
@@ -84,9 +82,10 @@ type PureWebSocketSubscriber struct {
 	realtimeEndpoint string
 	request          graphql.PostRequest
 	header           http.Header
-	sigv4            sigv4
+	signer           RequestSigner
 	cancel           context.CancelFunc
 	op               *realtimeWebSocketOperation
+	status           subscriberStatus
 }
 
 // NewPureWebSocketSubscriber returns a PureWebSocketSubscriber instance.
@@ -96,32 +95,41 @@ func NewPureWebSocketSubscriber(realtimeEndpoint string, request graphql.PostReq
 	opts ...PureWebSocketSubscriberOption) *PureWebSocketSubscriber {
 	slog.Debug("creating new pure websocket subscriber", "realtimeEndpoint", realtimeEndpoint, "request", request)
 	ctx, cancel := context.WithCancel(context.Background())
-	p := PureWebSocketSubscriber{
+	p := &PureWebSocketSubscriber{
 		realtimeEndpoint: realtimeEndpoint,
 		request:          request,
 		header:           http.Header{},
 		cancel:           cancel,
-		op:               newRealtimeWebSocketOperation(ctx, onReceive, onConnectionLost),
 	}
+	p.op = newRealtimeWebSocketOperation(ctx, onReceive, func(err error) {
+		p.status.set(SubscriberFailed, err)
+		onConnectionLost(err)
+	})
 	for _, opt := range opts {
-		opt(&p)
+		opt(p)
 	}
-	return &p
+	return p
+}
+
+func (p *PureWebSocketSubscriber) setupHeaders(payload []byte, connect bool) (map[string]string, error) {
+	return authorizationHeaders(p.op.ctx, p.header, p.signer, payload, connect)
 }
 
-func (p *PureWebSocketSubscriber) setupHeaders(payload []byte) (map[string]string, error) {
+// authorizationHeaders returns the realtime authorization headers for the payload of the connection
+// when connect is true or of a subscription otherwise, signed with signer if set, or taken from header otherwise.
+func authorizationHeaders(ctx context.Context, header http.Header, signer RequestSigner, payload []byte, connect bool) (map[string]string, error) {
 	slog.Debug("setting up headers", "payload", string(payload))
-	if p.sigv4 == nil {
+	if signer == nil {
 		slog.Debug("no sigV4")
 		headers := map[string]string{}
-		for k := range p.header {
-			headers[k] = p.header.Get(k)
+		for k := range header {
+			headers[k] = header.Get(k)
 		}
 		return headers, nil
 	}
 
 	slog.Debug("signing ws headers", "payload", string(payload))
-	headers, err := p.sigv4.signWS(payload)
+	headers, err := signer.SignWebSocket(ctx, payload, connect)
 	if err != nil {
 		slog.Error("error signing WS headers", "error", err)
 		return nil, err
@@ -132,8 +140,35 @@ func (p *PureWebSocketSubscriber) setupHeaders(payload []byte) (map[string]strin
 
 // Start starts a new subscription.
 func (p *PureWebSocketSubscriber) Start() error {
+	if err := p.status.begin(); err != nil {
+		return err
+	}
+	if err := p.start(); err != nil {
+		p.status.set(SubscriberFailed, err)
+		return err
+	}
+	p.status.set(SubscriberStarted, nil)
+	return nil
+}
+
+// StartContext is like Start but aborts the subscriber when ctx is done before it has started.
+func (p *PureWebSocketSubscriber) StartContext(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Start() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		p.abort()
+		<-errCh
+		p.status.set(SubscriberFailed, ctx.Err())
+		return ctx.Err()
+	}
+}
+
+func (p *PureWebSocketSubscriber) start() error {
 	bpayload := []byte("{}")
-	header, err := p.setupHeaders(bpayload)
+	header, err := p.setupHeaders(bpayload, true)
 	if err != nil {
 		slog.Error("error setting up headers", "error", err)
 		return err
@@ -158,7 +193,7 @@ func (p *PureWebSocketSubscriber) Start() error {
 		slog.ErrorContext(p.op.ctx, "error marshalling request", "error", err, "request", p.request)
 		return err
 	}
-	authz, err := p.setupHeaders(brequest)
+	authz, err := p.setupHeaders(brequest, false)
 	if err != nil {
 		slog.ErrorContext(p.op.ctx, "error setting up headers", "error", err)
 		return err
@@ -173,15 +208,43 @@ func (p *PureWebSocketSubscriber) Start() error {
 
 // Stop ends the subscription.
 func (p *PureWebSocketSubscriber) Stop() {
+	p.teardown()
+	p.status.stop()
+}
+
+func (p *PureWebSocketSubscriber) teardown() {
 	p.op.stop()
 	p.op.disconnect()
 }
 
+// StopContext is like Stop but returns ctx.Err() when ctx is done before the subscription has ended.
+func (p *PureWebSocketSubscriber) StopContext(ctx context.Context) error {
+	return stopContext(ctx, p.Stop)
+}
+
+// State returns the current state of the subscriber.
+func (p *PureWebSocketSubscriber) State() SubscriberState {
+	state, _ := p.status.get()
+	return state
+}
+
+// Err returns the error which failed starting or the connection last, or nil.
+func (p *PureWebSocketSubscriber) Err() error {
+	_, err := p.status.get()
+	return err
+}
+
 // Abort ends the subscription forcibly.
 func (p *PureWebSocketSubscriber) Abort() {
+	p.abort()
+	p.status.stop()
+}
+
+// abort ends the subscription forcibly without stopping the subscriber for good.
+func (p *PureWebSocketSubscriber) abort() {
 	p.cancel()
 	p.op.subscriptionID = ""
-	p.Stop()
+	p.teardown()
 }
 
 const defaultTimeout = time.Duration(300000) * time.Millisecond
@@ -259,9 +322,7 @@ func (r *realtimeWebSocketOperation) connect(realtimeEndpoint string, header, pa
 		return errors.New("already connected")
 	}
 
-	b64h := base64.StdEncoding.EncodeToString(header)
-	b64p := base64.StdEncoding.EncodeToString(payload)
-	endpoint := fmt.Sprintf("%s?header=%s&payload=%s", realtimeEndpoint, b64h, b64p)
+	endpoint := realtimeURL(realtimeEndpoint, header, payload)
 
 	connect := func() (string, error) {
 		ws, _, err := websocket.DefaultDialer.DialContext(r.ctx, endpoint, http.Header{"sec-websocket-protocol": []string{"graphql-ws"}})
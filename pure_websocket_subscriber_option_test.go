@@ -4,7 +4,6 @@ import (
 	"strings"
 	"testing"
 
-	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/sony/appsync-client-go/graphql"
 )
 
@@ -112,37 +111,3 @@ func TestWithOIDC(t *testing.T) {
 		})
 	}
 }
-
-func TestWithIAM(t *testing.T) {
-	type args struct {
-		signer *v4.Signer
-		region string
-		host   string
-	}
-	tests := []struct {
-		name string
-		args args
-	}{
-		{
-			name: "WithIAM Success",
-			args: args{
-				signer: &v4.Signer{},
-				region: "region",
-				host:   "host",
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := NewPureWebSocketSubscriber(realtimeEndpoint, request, onReceive, onConnectionLost)
-			if s.sigv4 != nil {
-				t.Fatal(s.sigv4)
-			}
-			opt := WithIAM(tt.args.signer, tt.args.region, tt.args.host)
-			opt(s)
-			if s.sigv4 == nil {
-				t.Fatal(s.sigv4)
-			}
-		})
-	}
-}
@@ -1,7 +1,9 @@
 package appsync
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -39,11 +41,33 @@ func TestPureWebSocketSubscriber_StartStop(t *testing.T) {
 			if err := p.Start(); (err != nil) != tt.wantErr {
 				t.Errorf("PureWebSocketSubscriber.Start() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if p.State() != SubscriberStarted {
+				t.Errorf("state: %s", p.State())
+			}
 			p.Stop()
+			if p.State() != SubscriberStopped {
+				t.Errorf("state: %s", p.State())
+			}
 		})
 	}
 }
 
+func TestPureWebSocketSubscriber_StartContext(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(NewPureWebSocketHandlerFunc(0, 0, 0)))
+	p := NewPureWebSocketSubscriber(strings.Replace(s.URL, "http", "ws", 1),
+		graphql.PostRequest{}, func(response *graphql.Response) {}, func(err error) {})
+	s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := p.StartContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got: %v", err)
+	}
+	if p.State() != SubscriberFailed || !errors.Is(p.Err(), context.DeadlineExceeded) {
+		t.Errorf("got: %s, %v", p.State(), p.Err())
+	}
+}
+
 func TestPureWebSocketSubscriber_AbortStartOnBackOff(t *testing.T) {
 
 	tests := []struct {
@@ -0,0 +1,340 @@
+package appsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+var errConnectionClosed = errors.New("connection closed")
+
+type realtimeSubscription struct {
+	onReceive func(response *graphql.Response)
+	started   chan error
+	completed chan struct{}
+	// acked is only accessed by the read loop.
+	acked bool
+}
+
+// realtimeConnection is a pure websocket connection shared by several subscriptions.
+type realtimeConnection struct {
+	onConnectionLost func(err error)
+
+	writeMu sync.Mutex
+	ws      *websocket.Conn
+
+	mu            sync.Mutex
+	subscriptions map[string]*realtimeSubscription
+	closing       bool
+	done          chan struct{}
+
+	// callbacks are run in order by the dispatch loop rather than the read loop,
+	// so that a callback stopping its subscription does not block the read loop waiting for the completion.
+	callbackMu sync.Mutex
+	callbacks  []func()
+	callbackCh chan struct{}
+}
+
+func dialRealtimeConnection(ctx context.Context, realtimeEndpoint string, header, payload []byte,
+	onConnectionLost func(err error)) (*realtimeConnection, error) {
+	endpoint := realtimeURL(realtimeEndpoint, header, payload)
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, endpoint, http.Header{"sec-websocket-protocol": []string{"graphql-ws"}})
+	if err != nil {
+		slog.ErrorContext(ctx, "error connecting to websocket", "error", err)
+		return nil, err
+	}
+
+	c := &realtimeConnection{
+		onConnectionLost: onConnectionLost,
+		ws:               ws,
+		subscriptions:    map[string]*realtimeSubscription{},
+		done:             make(chan struct{}),
+		callbackCh:       make(chan struct{}, 1),
+	}
+	connack := make(chan connectionAckMessage, 1)
+	go c.readLoop(connack)
+	go c.dispatchLoop()
+
+	if err := c.write(connectionInitMsg); err != nil {
+		c.close()
+		return nil, err
+	}
+	select {
+	case <-connack:
+	case <-c.done:
+		c.close()
+		return nil, errors.New("connection failed")
+	case <-ctx.Done():
+		c.close()
+		return nil, ctx.Err()
+	}
+	return c, nil
+}
+
+func (c *realtimeConnection) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("error marshalling message", "error", err)
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, b)
+}
+
+func (c *realtimeConnection) readLoop(connack chan<- connectionAckMessage) {
+	defer close(c.done)
+	defer c.shutdown()
+
+	timeout := defaultTimeout
+	for {
+		if err := c.ws.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			slog.Error("error setting read deadline", "error", err)
+			return
+		}
+		_, payload, err := c.ws.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			closing := c.closing
+			c.mu.Unlock()
+			if !closing {
+				slog.Warn("realtime connection lost", "error", err)
+				c.onConnectionLost(err)
+			}
+			return
+		}
+
+		msg := new(struct {
+			message
+			ID string `json:"id"`
+		})
+		if err := json.Unmarshal(payload, msg); err != nil {
+			slog.Error("error unmarshalling message", "error", err, "payload", string(payload))
+			continue
+		}
+		slog.Debug("msg", "payload", string(payload))
+
+		switch msg.Type {
+		case "connection_ack":
+			ack := new(connectionAckMessage)
+			if err := json.Unmarshal(payload, ack); err != nil {
+				slog.Error("error unmarshalling connection_ack", "error", err)
+				return
+			}
+			if ack.Payload.ConnectionTimeoutMs != 0 {
+				timeout = time.Duration(ack.Payload.ConnectionTimeoutMs) * time.Millisecond
+			}
+			select {
+			case connack <- *ack:
+			default:
+			}
+		case "ka":
+		case "start_ack":
+			if s, ok := c.subscription(msg.ID); ok && !s.acked {
+				s.acked = true
+				s.started <- nil
+			}
+		case "data":
+			data := new(processingDataMessage)
+			if err := json.Unmarshal(payload, data); err != nil {
+				slog.Error("error unmarshalling data", "error", err)
+				continue
+			}
+			if s, ok := c.subscription(msg.ID); ok {
+				id, response := msg.ID, &graphql.Response{Data: data.Payload.Data}
+				c.dispatch(func() {
+					// Data still queued when the subscription is stopped is dropped.
+					if _, ok := c.subscription(id); ok {
+						s.onReceive(response)
+					}
+				})
+			}
+		case "error":
+			c.onError(msg.ID, payload)
+		case "complete":
+			if s, ok := c.remove(msg.ID); ok {
+				close(s.completed)
+			}
+		default:
+			slog.Warn("invalid message received", "msgType", msg.Type)
+		}
+	}
+}
+
+func (c *realtimeConnection) onError(id string, payload []byte) {
+	em := new(errorMessage)
+	if err := json.Unmarshal(payload, em); err != nil {
+		slog.Error("error unmarshalling error", "error", err, "payload", string(payload))
+		return
+	}
+	s, ok := c.remove(id)
+	if !ok {
+		slog.Warn("error received", "payload", string(payload))
+		return
+	}
+	errs := make([]interface{}, len(em.Payload.Errors))
+	for i, e := range em.Payload.Errors {
+		errs[i] = e
+	}
+	if s.acked {
+		c.dispatch(func() { s.onReceive(&graphql.Response{Errors: &errs}) })
+	} else {
+		s.started <- fmt.Errorf("subscription registration failed: %v", errs)
+	}
+	close(s.completed)
+}
+
+// dispatch queues the callback for the dispatch loop without blocking.
+func (c *realtimeConnection) dispatch(f func()) {
+	c.callbackMu.Lock()
+	c.callbacks = append(c.callbacks, f)
+	c.callbackMu.Unlock()
+	select {
+	case c.callbackCh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *realtimeConnection) dispatchLoop() {
+	for {
+		select {
+		case <-c.callbackCh:
+		case <-c.done:
+			return
+		}
+		for {
+			c.callbackMu.Lock()
+			if len(c.callbacks) == 0 {
+				c.callbackMu.Unlock()
+				break
+			}
+			f := c.callbacks[0]
+			c.callbacks = c.callbacks[1:]
+			c.callbackMu.Unlock()
+			f()
+		}
+	}
+}
+
+func (c *realtimeConnection) subscription(id string) (*realtimeSubscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.subscriptions[id]
+	return s, ok
+}
+
+func (c *realtimeConnection) remove(id string) (*realtimeSubscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	return s, ok
+}
+
+// subscribe registers the subscription and sends its start message, returning the subscription to wait for.
+func (c *realtimeConnection) subscribe(request []byte, authorization map[string]string,
+	onReceive func(response *graphql.Response)) (string, *realtimeSubscription, error) {
+	id := uuid.New().String()
+	s := &realtimeSubscription{
+		onReceive: onReceive,
+		started:   make(chan error, 1),
+		completed: make(chan struct{}),
+	}
+	c.mu.Lock()
+	c.subscriptions[id] = s
+	c.mu.Unlock()
+
+	start := startMessage{
+		message: message{"start"},
+		ID:      id,
+		Payload: subscriptionRegistrationPayload{
+			Data: string(request),
+			Extensions: subscriptionRegistrationPayloadExtensions{
+				Authorization: authorization,
+			},
+		},
+	}
+	if err := c.write(start); err != nil {
+		c.remove(id)
+		return "", nil, err
+	}
+	return id, s, nil
+}
+
+// waitStarted waits for the start_ack of the subscription.
+func (c *realtimeConnection) waitStarted(ctx context.Context, id string, s *realtimeSubscription) error {
+	select {
+	case err := <-s.started:
+		return err
+	case <-c.done:
+		return errConnectionClosed
+	case <-ctx.Done():
+		c.remove(id)
+		return ctx.Err()
+	}
+}
+
+// unsubscribe stops the subscription and waits for its completion.
+func (c *realtimeConnection) unsubscribe(ctx context.Context, id string) {
+	s, ok := c.subscription(id)
+	if !ok {
+		return
+	}
+	if err := c.write(stopMessage{message{"stop"}, id}); err != nil {
+		slog.Warn("error writing stop", "error", err)
+		c.remove(id)
+		return
+	}
+	select {
+	case <-s.completed:
+	case <-c.done:
+	case <-ctx.Done():
+		c.remove(id)
+	}
+}
+
+// len returns the number of active subscriptions.
+func (c *realtimeConnection) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subscriptions)
+}
+
+func (c *realtimeConnection) close() {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	if err := c.ws.Close(); err != nil {
+		slog.Error("error closing websocket", "error", err)
+	}
+}
+
+// shutdown fails the subscriptions still waiting for their start_ack.
+func (c *realtimeConnection) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, s := range c.subscriptions {
+		if !s.acked {
+			s.started <- errConnectionClosed
+		}
+		delete(c.subscriptions, id)
+	}
+}
+
+func (c *realtimeConnection) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
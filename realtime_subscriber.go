@@ -0,0 +1,139 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SubscriberState is the state of a RealtimeSubscriber.
+type SubscriberState int
+
+const (
+	// SubscriberStopped is the state before starting and after stopping.
+	SubscriberStopped SubscriberState = iota
+	// SubscriberStarting is the state while connecting and subscribing.
+	SubscriberStarting
+	// SubscriberStarted is the state while receiving messages.
+	SubscriberStarted
+	// SubscriberReconnecting is the state while renewing lost or expiring connections.
+	SubscriberReconnecting
+	// SubscriberFailed is the state after failing to start or losing the connection for good.
+	SubscriberFailed
+)
+
+func (s SubscriberState) String() string {
+	switch s {
+	case SubscriberStopped:
+		return "stopped"
+	case SubscriberStarting:
+		return "starting"
+	case SubscriberStarted:
+		return "started"
+	case SubscriberReconnecting:
+		return "reconnecting"
+	case SubscriberFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+var (
+	// ErrSubscriberStarted is returned when starting a subscriber which is already starting or running.
+	ErrSubscriberStarted = errors.New("subscriber already started")
+	// ErrSubscriberStopped is returned when starting a subscriber which has been stopped.
+	ErrSubscriberStopped = errors.New("subscriber stopped")
+)
+
+// RealtimeSubscriber is a subscription over any transport, implemented by Subscriber, PureWebSocketSubscriber
+// and SubscriptionHandle. A stopped subscription cannot be started again.
+type RealtimeSubscriber interface {
+	// Start starts the subscription. It fails with ErrSubscriberStarted while the subscription is starting or running,
+	// and with ErrSubscriberStopped once it has been stopped, but it may be retried after failing.
+	Start() error
+	// StartContext starts the subscription, aborting when ctx is done.
+	StartContext(ctx context.Context) error
+	// Stop ends the subscription.
+	Stop()
+	// StopContext ends the subscription, returning when ctx is done even if it has not ended yet.
+	StopContext(ctx context.Context) error
+	// State returns the current state.
+	State() SubscriberState
+	// Err returns the error which failed the subscription last, or nil.
+	Err() error
+}
+
+var (
+	_ RealtimeSubscriber = (*Subscriber)(nil)
+	_ RealtimeSubscriber = (*PureWebSocketSubscriber)(nil)
+)
+
+type subscriberStatus struct {
+	mu      sync.RWMutex
+	state   SubscriberState
+	err     error
+	stopped bool
+}
+
+// begin moves to SubscriberStarting unless the subscriber has been stopped,
+// or is starting or running since it is neither stopped nor failed.
+func (s *subscriberStatus) begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrSubscriberStopped
+	}
+	if s.state != SubscriberStopped && s.state != SubscriberFailed {
+		return ErrSubscriberStarted
+	}
+	s.state = SubscriberStarting
+	return nil
+}
+
+// stop moves to SubscriberStopped for good.
+func (s *subscriberStatus) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = SubscriberStopped
+	s.stopped = true
+}
+
+func (s *subscriberStatus) isStopped() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stopped
+}
+
+// set moves to state, unless the subscriber has been stopped for good.
+func (s *subscriberStatus) set(state SubscriberState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.state = state
+	if err != nil {
+		s.err = err
+	}
+}
+
+func (s *subscriberStatus) get() (SubscriberState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state, s.err
+}
+
+// stopContext runs stop and waits for it until ctx is done.
+func stopContext(ctx context.Context, stop func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stop()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func TestSubscriberState_String(t *testing.T) {
+	tests := []struct {
+		state SubscriberState
+		want  string
+	}{
+		{SubscriberStopped, "stopped"},
+		{SubscriberStarting, "starting"},
+		{SubscriberStarted, "started"},
+		{SubscriberReconnecting, "reconnecting"},
+		{SubscriberFailed, "failed"},
+		{SubscriberState(42), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("want: %s, got: %s", tt.want, got)
+		}
+	}
+}
+
+func TestStopContext(t *testing.T) {
+	if err := stopContext(context.Background(), func() {}); err != nil {
+		t.Error(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	if err := stopContext(ctx, func() { <-release }); err != context.DeadlineExceeded {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestRealtimeSubscriber_StartAfterStop(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	endpoint := strings.Replace(server.URL, "http", "ws", 1)
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	subscription := graphql.PostRequest{Query: `subscription SubscribeToEcho() { subscribeToEcho }`}
+	handle, err := client.NewSubscriptionHandle(subscription, SubscriptionConfig{Transport: TransportWebSocket, RealtimeEndpoint: endpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mqtt, err := NewSubscriber(newTestExtensions(t, endpoint), func(*graphql.Response) {}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]RealtimeSubscriber{
+		"mqtt":      mqtt,
+		"websocket": NewPureWebSocketSubscriber(endpoint, subscription, func(*graphql.Response) {}, func(error) {}),
+		"handle":    handle,
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Start(); err != nil {
+				t.Fatal(err)
+			}
+			s.Stop()
+			if err := s.Start(); !errors.Is(err, ErrSubscriberStopped) {
+				t.Errorf("got: %v", err)
+			}
+			if s.State() != SubscriberStopped {
+				t.Errorf("state: %s", s.State())
+			}
+		})
+	}
+}
+
+func TestRealtimeSubscriber_StartTwice(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	endpoint := strings.Replace(server.URL, "http", "ws", 1)
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	subscription := graphql.PostRequest{Query: `subscription SubscribeToEcho() { subscribeToEcho }`}
+	handle, err := client.NewSubscriptionHandle(subscription, SubscriptionConfig{Transport: TransportWebSocket, RealtimeEndpoint: endpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mqtt, err := NewSubscriber(newTestExtensions(t, endpoint), func(*graphql.Response) {}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]RealtimeSubscriber{
+		"mqtt":      mqtt,
+		"websocket": NewPureWebSocketSubscriber(endpoint, subscription, func(*graphql.Response) {}, func(error) {}),
+		"handle":    handle,
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Stop()
+			if err := s.Start(); !errors.Is(err, ErrSubscriberStarted) {
+				t.Errorf("got: %v", err)
+			}
+			if s.State() != SubscriberStarted {
+				t.Errorf("state: %s", s.State())
+			}
+			if err := s.Err(); err != nil {
+				t.Errorf("err: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package scalars
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]+([ -]?[0-9]+)*$`)
+
+// AWSEmail is an email address in the format local-part@domain-part as defined by RFC 822.
+type AWSEmail string
+
+// Validate checks if e is a valid AWSEmail.
+func (e AWSEmail) Validate() error {
+	a, err := mail.ParseAddress(string(e))
+	if err != nil || a.Address != string(e) || !strings.Contains(a.Address[strings.LastIndex(a.Address, "@"):], ".") {
+		return fmt.Errorf("invalid AWSEmail %q", string(e))
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e AWSEmail) MarshalJSON() ([]byte, error) {
+	return marshalString(string(e), e.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *AWSEmail) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSEmail", e)
+}
+
+// AWSURL is a URL as defined by RFC 1738. It must contain a scheme and can't contain two forward slashes in the path.
+type AWSURL string
+
+// Validate checks if u is a valid AWSURL.
+func (u AWSURL) Validate() error {
+	p, err := url.Parse(string(u))
+	if err != nil || len(p.Scheme) == 0 || strings.Contains(p.Path, "//") {
+		return fmt.Errorf("invalid AWSURL %q", string(u))
+	}
+	return nil
+}
+
+// URL returns the parsed URL.
+func (u AWSURL) URL() (*url.URL, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	return url.Parse(string(u))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u AWSURL) MarshalJSON() ([]byte, error) {
+	return marshalString(string(u), u.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *AWSURL) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSURL", u)
+}
+
+// AWSPhone is a phone number, optionally starting with + and a country code,
+// whose digit groups may be separated by spaces or hyphens.
+type AWSPhone string
+
+// Validate checks if p is a valid AWSPhone.
+func (p AWSPhone) Validate() error {
+	if !phonePattern.MatchString(string(p)) {
+		return fmt.Errorf("invalid AWSPhone %q", string(p))
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p AWSPhone) MarshalJSON() ([]byte, error) {
+	return marshalString(string(p), p.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *AWSPhone) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSPhone", p)
+}
+
+// AWSIPAddress is an IPv4 or IPv6 address, optionally in CIDR notation.
+type AWSIPAddress string
+
+// Validate checks if ip is a valid AWSIPAddress.
+func (ip AWSIPAddress) Validate() error {
+	_, err := ip.Prefix()
+	return err
+}
+
+// Prefix returns the address as a prefix, whose length is the full address length without CIDR notation.
+func (ip AWSIPAddress) Prefix() (netip.Prefix, error) {
+	s := string(ip)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid AWSIPAddress %q", s)
+		}
+		return p, nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil || a.Zone() != "" {
+		return netip.Prefix{}, fmt.Errorf("invalid AWSIPAddress %q", s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (ip AWSIPAddress) MarshalJSON() ([]byte, error) {
+	return marshalString(string(ip), ip.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ip *AWSIPAddress) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSIPAddress", ip)
+}
+
+// AWSJSON is a JSON document. It is transferred as a JSON-encoded string.
+type AWSJSON json.RawMessage
+
+// NewAWSJSON returns the AWSJSON encoding of v.
+func NewAWSJSON(v interface{}) (AWSJSON, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return AWSJSON(b), nil
+}
+
+// Validate checks if j is a valid JSON document.
+func (j AWSJSON) Validate() error {
+	if !json.Valid(j) {
+		return fmt.Errorf("invalid AWSJSON %q", string(j))
+	}
+	return nil
+}
+
+// Unmarshal decodes the JSON document into v.
+func (j AWSJSON) Unmarshal(v interface{}) error {
+	return json.Unmarshal(j, v)
+}
+
+// MarshalJSON implements json.Marshaler. The document is encoded as a string.
+func (j AWSJSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	if err := j.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(j))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The document must be encoded as a string.
+func (j *AWSJSON) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*j = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("AWSJSON must be a string: %w", err)
+	}
+	if err := AWSJSON(s).Validate(); err != nil {
+		return err
+	}
+	*j = AWSJSON(s)
+	return nil
+}
@@ -0,0 +1,141 @@
+package scalars
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scalar  interface{ Validate() error }
+		wantErr bool
+	}{
+		{name: "email", scalar: AWSEmail("example@example.com")},
+		{name: "email with name", scalar: AWSEmail("Example <example@example.com>"), wantErr: true},
+		{name: "email without domain", scalar: AWSEmail("example@localhost"), wantErr: true},
+		{name: "email without at", scalar: AWSEmail("example.com"), wantErr: true},
+		{name: "url", scalar: AWSURL("https://example.com/path?q=1")},
+		{name: "mailto url", scalar: AWSURL("mailto:example@example.com")},
+		{name: "url without scheme", scalar: AWSURL("example.com/path"), wantErr: true},
+		{name: "url with double slash", scalar: AWSURL("https://example.com//path"), wantErr: true},
+		{name: "phone", scalar: AWSPhone("+1 555 123 4567")},
+		{name: "phone with hyphens", scalar: AWSPhone("555-123-4567")},
+		{name: "phone with letters", scalar: AWSPhone("555-CALL"), wantErr: true},
+		{name: "phone with double separator", scalar: AWSPhone("555--123"), wantErr: true},
+		{name: "ipv4", scalar: AWSIPAddress("127.0.0.1")},
+		{name: "ipv4 cidr", scalar: AWSIPAddress("10.0.0.0/8")},
+		{name: "ipv6", scalar: AWSIPAddress("::1")},
+		{name: "ipv6 cidr", scalar: AWSIPAddress("2001:db8::/32")},
+		{name: "invalid ip", scalar: AWSIPAddress("256.0.0.1"), wantErr: true},
+		{name: "invalid cidr", scalar: AWSIPAddress("10.0.0.0/33"), wantErr: true},
+		{name: "json", scalar: AWSJSON(`{"key":"value"}`)},
+		{name: "json scalar", scalar: AWSJSON(`1`)},
+		{name: "invalid json", scalar: AWSJSON(`{key}`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scalar.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAWSIPAddress_Prefix(t *testing.T) {
+	p, err := AWSIPAddress("192.168.1.1").Prefix()
+	if err != nil || p.Bits() != 32 {
+		t.Errorf("got: %v, %v", p, err)
+	}
+	p, err = AWSIPAddress("192.168.0.0/16").Prefix()
+	if err != nil || p.Bits() != 16 {
+		t.Errorf("got: %v, %v", p, err)
+	}
+}
+
+func TestAWSJSON(t *testing.T) {
+	j, err := NewAWSJSON(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(struct {
+		Data AWSJSON `json:"data"`
+	}{j})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"data":"{\"a\":1}"}` {
+		t.Errorf("got: %s", b)
+	}
+
+	var v struct {
+		Data AWSJSON `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]int{}
+	if err := v.Data.Unmarshal(&m); err != nil || m["a"] != 1 {
+		t.Errorf("got: %v, %v", m, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"data":{"a":1}}`), &v); err == nil {
+		t.Error("a JSON object should be rejected, AWSJSON is a string")
+	}
+	if err := json.Unmarshal([]byte(`{"data":null}`), &v); err != nil || v.Data != nil {
+		t.Errorf("got: %v, %v", v.Data, err)
+	}
+}
+
+func TestDataAs(t *testing.T) {
+	type contact struct {
+		Email   AWSEmail     `json:"email"`
+		Website AWSURL       `json:"website"`
+		Phone   AWSPhone     `json:"phone"`
+		IP      AWSIPAddress `json:"ip"`
+		Updated AWSDateTime  `json:"updated"`
+		Extra   AWSJSON      `json:"extra"`
+	}
+	response := graphql.Response{Data: map[string]interface{}{
+		"getContact": map[string]interface{}{
+			"email":   "example@example.com",
+			"website": "https://example.com",
+			"phone":   "+81 3-1234-5678",
+			"ip":      "203.0.113.1",
+			"updated": "2024-01-02T03:04:05.678+09:00",
+			"extra":   `{"tags":["a"]}`,
+		},
+	}}
+	c := new(contact)
+	if err := response.DataAs(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Email != "example@example.com" || c.Phone != "+81 3-1234-5678" || string(c.Extra) != `{"tags":["a"]}` {
+		t.Errorf("got: %+v", c)
+	}
+
+	response.Data.(map[string]interface{})["getContact"].(map[string]interface{})["email"] = "invalid"
+	if err := response.DataAs(c); err == nil {
+		t.Error("an invalid email should be rejected")
+	}
+}
+
+func TestVariables(t *testing.T) {
+	request, err := graphql.NewRequest("mutation Update($email: AWSEmail!, $at: AWSDateTime!) { update }").
+		Var("email", AWSEmail("example@example.com")).
+		Var("at", AWSDateTime("2024-01-02T03:04:05Z")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(*request.Variables) != `{"at":"2024-01-02T03:04:05Z","email":"example@example.com"}` {
+		t.Errorf("got: %s", *request.Variables)
+	}
+
+	if _, err := graphql.NewRequest("mutation Update($email: AWSEmail!) { update }").
+		Var("email", AWSEmail("invalid")).Build(); err == nil {
+		t.Error("an invalid email should be rejected")
+	}
+}
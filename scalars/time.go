@@ -0,0 +1,183 @@
+// Package scalars provides Go types for the AWS AppSync scalar types.
+//
+// The types validate their values following the AppSync rules when they are marshalled to or
+// unmarshalled from JSON, so they can be used both in request variables and in response structs
+// decoded by graphql.Response.DataAs.
+package scalars
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	zoneLayouts     = []string{"", "Z07:00", "Z07:00:00"}
+	dateLayouts     = withZones([]string{"2006-01-02"}, zoneLayouts)
+	timeLayouts     = withZones([]string{"15:04", "15:04:05"}, zoneLayouts)
+	dateTimeLayouts = withZones([]string{"2006-01-02T15:04", "2006-01-02T15:04:05"}, zoneLayouts[1:])
+)
+
+func withZones(layouts, zones []string) []string {
+	ret := []string{}
+	for _, l := range layouts {
+		for _, z := range zones {
+			ret = append(ret, l+z)
+		}
+	}
+	return ret
+}
+
+func parse(kind, s string, layouts []string) (time.Time, error) {
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q", kind, s)
+}
+
+func marshalString(s string, validate func() error) ([]byte, error) {
+	if err := validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+type stringScalar interface {
+	~string
+	Validate() error
+}
+
+// unmarshalString decodes and validates a string scalar. null leaves v unchanged.
+func unmarshalString[T stringScalar](b []byte, kind string, v *T) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("%s must be a string: %w", kind, err)
+	}
+	if err := T(s).Validate(); err != nil {
+		return err
+	}
+	*v = T(s)
+	return nil
+}
+
+// AWSDate is an extended ISO 8601 date string in the format YYYY-MM-DD, with an optional time zone offset.
+type AWSDate string
+
+// NewAWSDate returns the AWSDate of t, without time zone offset.
+func NewAWSDate(t time.Time) AWSDate {
+	return AWSDate(t.Format("2006-01-02"))
+}
+
+// Validate checks if d is a valid AWSDate.
+func (d AWSDate) Validate() error {
+	_, err := d.Time()
+	return err
+}
+
+// Time returns the time at midnight of the date, in UTC when d has no time zone offset.
+func (d AWSDate) Time() (time.Time, error) {
+	return parse("AWSDate", string(d), dateLayouts)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d AWSDate) MarshalJSON() ([]byte, error) {
+	return marshalString(string(d), d.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *AWSDate) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSDate", d)
+}
+
+// AWSTime is an extended ISO 8601 time string in the format hh:mm:ss.sss, with optional seconds,
+// fractional seconds and time zone offset.
+type AWSTime string
+
+// NewAWSTime returns the AWSTime of t with its time zone offset.
+func NewAWSTime(t time.Time) AWSTime {
+	return AWSTime(t.Format("15:04:05.999999999Z07:00"))
+}
+
+// Validate checks if t is a valid AWSTime.
+func (t AWSTime) Validate() error {
+	_, err := t.Time()
+	return err
+}
+
+// Time returns the time of day on January 1, year 0, in UTC when t has no time zone offset.
+func (t AWSTime) Time() (time.Time, error) {
+	return parse("AWSTime", string(t), timeLayouts)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t AWSTime) MarshalJSON() ([]byte, error) {
+	return marshalString(string(t), t.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *AWSTime) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSTime", t)
+}
+
+// AWSDateTime is an extended ISO 8601 date and time string in the format YYYY-MM-DDThh:mm:ss.sssZ.
+// The time zone offset is required.
+type AWSDateTime string
+
+// NewAWSDateTime returns the AWSDateTime of t with its time zone offset.
+func NewAWSDateTime(t time.Time) AWSDateTime {
+	return AWSDateTime(t.Format(time.RFC3339Nano))
+}
+
+// Validate checks if dt is a valid AWSDateTime.
+func (dt AWSDateTime) Validate() error {
+	_, err := dt.Time()
+	return err
+}
+
+// Time returns the time of dt.
+func (dt AWSDateTime) Time() (time.Time, error) {
+	return parse("AWSDateTime", string(dt), dateTimeLayouts)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (dt AWSDateTime) MarshalJSON() ([]byte, error) {
+	return marshalString(string(dt), dt.Validate)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (dt *AWSDateTime) UnmarshalJSON(b []byte) error {
+	return unmarshalString(b, "AWSDateTime", dt)
+}
+
+// AWSTimestamp is the number of seconds elapsed since 1970-01-01T00:00Z.
+type AWSTimestamp int64
+
+// NewAWSTimestamp returns the AWSTimestamp of t.
+func NewAWSTimestamp(t time.Time) AWSTimestamp {
+	return AWSTimestamp(t.Unix())
+}
+
+// Time returns the time of ts.
+func (ts AWSTimestamp) Time() time.Time {
+	return time.Unix(int64(ts), 0)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Negative timestamps are accepted, fractions are rejected.
+func (ts *AWSTimestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid AWSTimestamp %s", string(b))
+	}
+	*ts = AWSTimestamp(n)
+	return nil
+}
@@ -0,0 +1,148 @@
+package scalars
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAWSDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "1970-01-01", want: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "1970-01-01Z", want: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "1970-01-01-07:00", want: time.Date(1970, 1, 1, 7, 0, 0, 0, time.UTC)},
+		{value: "1970-01-01+05:30:10", want: time.Date(1969, 12, 31, 18, 29, 50, 0, time.UTC)},
+		{value: "1970-13-01", wantErr: true},
+		{value: "1970-01-01T00:00Z", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := AWSDate(tt.value).Time()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Time() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+	if d := NewAWSDate(time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC)); d != "2024-02-29" {
+		t.Errorf("NewAWSDate() = %s", d)
+	}
+}
+
+func TestAWSTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "12:30", want: time.Date(0, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{value: "12:30:24", want: time.Date(0, 1, 1, 12, 30, 24, 0, time.UTC)},
+		{value: "12:30:24.500", want: time.Date(0, 1, 1, 12, 30, 24, 500000000, time.UTC)},
+		{value: "12:30:24.500Z", want: time.Date(0, 1, 1, 12, 30, 24, 500000000, time.UTC)},
+		{value: "12:30:24-07:00", want: time.Date(0, 1, 1, 19, 30, 24, 0, time.UTC)},
+		{value: "25:00", wantErr: true},
+		{value: "12", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := AWSTime(tt.value).Time()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Time() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+	if tm := NewAWSTime(time.Date(2024, 1, 1, 1, 2, 3, 0, time.UTC)); tm != "01:02:03Z" {
+		t.Errorf("NewAWSTime() = %s", tm)
+	}
+}
+
+func TestAWSDateTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "1970-01-01T12:00Z", want: time.Date(1970, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "1970-01-01T12:00:00.123Z", want: time.Date(1970, 1, 1, 12, 0, 0, 123000000, time.UTC)},
+		{value: "1970-01-01T12:00:00+09:00", want: time.Date(1970, 1, 1, 3, 0, 0, 0, time.UTC)},
+		{value: "1970-01-01T12:00:00", wantErr: true},
+		{value: "1970-01-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := AWSDateTime(tt.value).Time()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Time() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+	now := time.Now()
+	got, err := NewAWSDateTime(now).Time()
+	if err != nil || !got.Equal(now) {
+		t.Errorf("NewAWSDateTime() round trip: %v, %v", got, err)
+	}
+}
+
+func TestAWSTimestamp(t *testing.T) {
+	var ts AWSTimestamp
+	if err := json.Unmarshal([]byte("1700000000"), &ts); err != nil {
+		t.Fatal(err)
+	}
+	if !ts.Time().Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("got: %v", ts.Time())
+	}
+	if err := json.Unmarshal([]byte("1.5"), &ts); err == nil {
+		t.Error("a fraction should be rejected")
+	}
+	if err := json.Unmarshal([]byte(`"1700000000"`), &ts); err == nil {
+		t.Error("a string should be rejected")
+	}
+	b, err := json.Marshal(NewAWSTimestamp(time.Unix(42, 0)))
+	if err != nil || string(b) != "42" {
+		t.Errorf("got: %s, %v", b, err)
+	}
+}
+
+func TestTimeScalarsJSON(t *testing.T) {
+	type event struct {
+		Date     AWSDate      `json:"date"`
+		Time     *AWSTime     `json:"time"`
+		DateTime AWSDateTime  `json:"dateTime"`
+		Created  AWSTimestamp `json:"created"`
+	}
+	in := `{"date":"2024-01-02","time":null,"dateTime":"2024-01-02T03:04:05Z","created":1704164645}`
+	var e event
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Date != "2024-01-02" || e.Time != nil || e.DateTime != "2024-01-02T03:04:05Z" || e.Created != 1704164645 {
+		t.Errorf("got: %+v", e)
+	}
+	out, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("got: %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"date":"2024-01-32"}`), &e); err == nil {
+		t.Error("an invalid date should be rejected")
+	}
+	if _, err := json.Marshal(event{Date: "invalid"}); err == nil {
+		t.Error("an invalid date should not be marshalled")
+	}
+}
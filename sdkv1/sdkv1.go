@@ -0,0 +1,45 @@
+// Package sdkv1 provides IAM authorization with the AWS SDK for Go v1 signer,
+// so that the appsync package depends only on the AWS SDK for Go v2.
+package sdkv1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	appsync "github.com/sony/appsync-client-go"
+)
+
+// NewRequestSigner returns an appsync.RequestSigner signing with the sdk v1 signature version 4 signer for the API url in the region.
+func NewRequestSigner(signer *v4.Signer, region, url string, opts ...appsync.RequestSignerOption) appsync.RequestSigner {
+	return appsync.NewRequestSigner(func(ctx context.Context, req *http.Request, payload []byte, signTime time.Time) error {
+		_, err := signer.Sign(req, bytes.NewReader(payload), "appsync", region, signTime)
+		return err
+	}, url, opts...)
+}
+
+// WithIAMAuthorization returns an appsync.ClientOption configured with the given sdk v1 signature version 4 signer.
+//
+// Deprecated: for backward compatibility.
+func WithIAMAuthorization(signer v4.Signer, region, host string) appsync.ClientOption {
+	return WithIAMAuthorizationV1(&signer, region, host)
+}
+
+// WithIAMAuthorizationV1 returns an appsync.ClientOption configured with the given sdk v1 signature version 4 signer.
+func WithIAMAuthorizationV1(signer *v4.Signer, region, url string) appsync.ClientOption {
+	return appsync.WithRequestSigner(NewRequestSigner(signer, region, url))
+}
+
+// WithIAM returns an appsync.PureWebSocketSubscriberOption configured with the signature version 4 signer, the region and the host for the AWS AppSync GraphQL endpoint.
+//
+// Deprecated: for backward compatibility.
+func WithIAM(signer *v4.Signer, region, host string) appsync.PureWebSocketSubscriberOption {
+	return WithIAMV1(signer, region, host)
+}
+
+// WithIAMV1 returns an appsync.PureWebSocketSubscriberOption configured with the sdk v1 signature version 4 signer, the region and the url for the AWS AppSync GraphQL endpoint.
+func WithIAMV1(signer *v4.Signer, region, url string) appsync.PureWebSocketSubscriberOption {
+	return appsync.WithWebSocketRequestSigner(NewRequestSigner(signer, region, url))
+}
@@ -0,0 +1,111 @@
+package sdkv1
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	appsync "github.com/sony/appsync-client-go"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+var testSigner = v4.NewSigner(credentials.NewStaticCredentials("AKID", "SECRET", "TOKEN"))
+
+func TestNewRequestSigner(t *testing.T) {
+	s := NewRequestSigner(testSigner, "us-east-1", "wss://xxx.appsync-realtime-api.us-east-1.amazonaws.com/graphql")
+	headers, err := s.SignWebSocket(context.Background(), []byte("{}"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers["host"] != "xxx.appsync-api.us-east-1.amazonaws.com" || headers["X-Amz-Security-Token"] != "TOKEN" ||
+		!strings.Contains(headers["Authorization"], "Credential=AKID/") {
+		t.Errorf("got: %v", headers)
+	}
+	header, err := s.SignHTTP(context.Background(), []byte(`{"query":"query { message }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(header.Get("Authorization"), "/us-east-1/appsync/aws4_request") {
+		t.Errorf("got: %v", header)
+	}
+
+	clock := func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	s = NewRequestSigner(testSigner, "us-east-1", "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql", appsync.WithSigningClock(clock))
+	header, err = s.SignHTTP(context.Background(), []byte(`{"query":"query { message }"}`))
+	if err != nil || header.Get("X-Amz-Date") != "20240102T030405Z" {
+		t.Errorf("got: %v, %v", header, err)
+	}
+}
+
+func TestNewRequestSigner_clockSkew(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	s := NewRequestSigner(testSigner, "us-east-1", "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql", appsync.WithSigningClock(clock))
+	corrector, ok := s.(appsync.ClockSkewCorrector)
+	if !ok {
+		t.Fatal("the sdk v1 signer should correct its clock skew")
+	}
+	corrector.CorrectClockSkew(clock().Add(15 * time.Minute))
+	header, err := s.SignHTTP(context.Background(), []byte(`{"query":"query { message }"}`))
+	if err != nil || header.Get("X-Amz-Date") != "20240102T031905Z" {
+		t.Errorf("got: %v, %v", header, err)
+	}
+}
+
+func TestWithIAMAuthorizationV1(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"message": "hello"}}`))
+	}))
+	defer server.Close()
+
+	client := appsync.NewClient(appsync.NewGraphQLClient(graphql.NewClient(server.URL)),
+		WithIAMAuthorizationV1(testSigner, "us-east-1", server.URL))
+	if _, err := client.Post(graphql.PostRequest{Query: "query { message }"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-authorization; !strings.HasPrefix(got, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("got: %s", got)
+	}
+}
+
+func TestWithIAMV1(t *testing.T) {
+	header := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case header <- r.URL.Query().Get("header"):
+		default:
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	url := strings.Replace(server.URL, "http", "ws", 1)
+	s := appsync.NewPureWebSocketSubscriber(url, graphql.PostRequest{}, func(*graphql.Response) {}, func(error) {},
+		WithIAMV1(testSigner, "us-east-1", url))
+	defer s.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := s.StartContext(ctx); err == nil {
+		t.Fatal("the forbidden connection should fail")
+	}
+	b, err := base64.StdEncoding.DecodeString(<-header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]string{}
+	if err := json.Unmarshal(b, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(headers["Authorization"], "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("got: %v", headers)
+	}
+}
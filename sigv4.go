@@ -4,67 +4,193 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
-	"errors"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	sdkv2_v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
-	sdkv1_v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/sony/appsync-client-go/graphql"
 )
 
-type sigv4 interface {
-	signHTTP(payload []byte) (http.Header, error)
-	signWS(payload []byte) (map[string]string, error)
+// RequestSigner signs GraphQL requests and realtime messages for IAM authorization.
+// Implement it to sign with other credentials than the provided SDK adapters, or to fake signing in tests.
+type RequestSigner interface {
+	// SignHTTP returns the headers authorizing the GraphQL request with the payload.
+	SignHTTP(ctx context.Context, payload []byte) (http.Header, error)
+	// SignWebSocket returns the headers authorizing the realtime connection when connect is true,
+	// whose payload is "{}", or the subscription with the payload otherwise.
+	SignWebSocket(ctx context.Context, payload []byte, connect bool) (map[string]string, error)
+}
+
+// ClockSkewCorrector is implemented by RequestSigners which can sign at the AppSync time, such as the ones built
+// by NewRequestSigner. Client corrects them when AppSync rejects a request for an expired signature.
+type ClockSkewCorrector interface {
+	// CorrectClockSkew makes the signer sign at the AppSync time serverTime from now on.
+	CorrectClockSkew(serverTime time.Time)
+}
+
+// SignFunc signs req, whose body is payload, for the appsync service at signTime.
+type SignFunc func(ctx context.Context, req *http.Request, payload []byte, signTime time.Time) error
+
+// RequestSignerOption represents options for the RequestSigners built by this package.
+type RequestSignerOption func(*_signer)
+
+// WithSigningClock returns a RequestSignerOption signing at the times returned by clock instead of time.Now.
+func WithSigningClock(clock func() time.Time) RequestSignerOption {
+	return func(s *_signer) {
+		s.clock = clock
+	}
+}
+
+// WithSigningHost returns a RequestSignerOption signing for the host instead of the host of the API url,
+// such as the API host behind a VPC endpoint.
+func WithSigningHost(host string) RequestSignerOption {
+	return func(s *_signer) {
+		s.host = host
+	}
+}
+
+// NewRequestSigner returns a RequestSigner building the canonical AppSync requests of the API url and signing them with sign.
+func NewRequestSigner(sign SignFunc, url string, opts ...RequestSignerOption) RequestSigner {
+	s := &_signer{sign: sign, url: url, clock: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewSDKV2RequestSigner returns a RequestSigner signing with the sdk v2 signature version 4 signer and the credentials for the API url in the region.
+func NewSDKV2RequestSigner(signer *sdkv2_v4.Signer, creds aws.Credentials, region, url string, opts ...RequestSignerOption) RequestSigner {
+	return NewRequestSigner(func(ctx context.Context, req *http.Request, payload []byte, signTime time.Time) error {
+		hash := sha256.Sum256(payload)
+		return signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "appsync", region, signTime)
+	}, url, opts...)
 }
 
 type _signer struct {
-	sdkSigner any
-	region    string
-	url       string
+	sign  SignFunc
+	url   string
+	clock func() time.Time
+	// skew is the offset in nanoseconds from the clock to the AppSync clock, learned from expired signatures.
+	skew atomic.Int64
+	// host is the host to sign for instead of the host of url, such as the API host behind a VPC endpoint.
+	host string
+}
 
-	creds *aws.Credentials
+// now returns the signing time, which is the clock corrected by the known skew.
+func (s *_signer) now() time.Time {
+	return s.clock().Add(time.Duration(s.skew.Load()))
+}
+
+func (s *_signer) CorrectClockSkew(serverTime time.Time) {
+	skew := serverTime.Sub(s.clock())
+	slog.Warn("correcting clock skew of signer", "skew", skew)
+	s.skew.Store(int64(skew))
+}
+
+// signatureTimeError matches the AppSync time in the messages of expired or not yet current signatures,
+// such as "Signature expired: 20240101T000000Z is now earlier than 20240101T000500Z (20240101T001000Z - 5 min.)".
+var signatureTimeError = regexp.MustCompile(`\((\d{8}T\d{6}Z) [+-] \d+ min\.\)`)
+
+// signatureServerTime returns the AppSync time reported by an InvalidSignatureException in response.
+func signatureServerTime(response *graphql.Response) (time.Time, bool) {
+	if response == nil || response.Errors == nil {
+		return time.Time{}, false
+	}
+	for _, e := range *response.Errors {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, _ := m["message"].(string)
+		if m["errorType"] != "InvalidSignatureException" && !strings.HasPrefix(message, "Signature expired") &&
+			!strings.HasPrefix(message, "Signature not yet current") {
+			continue
+		}
+		match := signatureTimeError.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		t, err := time.Parse("20060102T150405Z", match[1])
+		if err != nil {
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// signingURL returns the canonical URL signed for GraphQL requests, or for realtime connections when connect is true.
+// Realtime hosts and paths in url are mapped to the GraphQL ones.
+func (s *_signer) signingURL(connect bool) (string, error) {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss", "":
+		u.Scheme = "https"
+	}
+	if len(s.host) != 0 {
+		u.Host = s.host
+	} else {
+		u.Host = strings.Replace(u.Host, ".appsync-realtime-api.", ".appsync-api.", 1)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	path = strings.TrimSuffix(path, "/connect")
+	path = strings.TrimSuffix(path, "/realtime")
+	if len(path) == 0 {
+		path = "/graphql"
+	}
+	if connect {
+		path += "/connect"
+	}
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = path, "", "", ""
+	return u.String(), nil
 }
 
-func (s *_signer) signHTTP(payload []byte) (http.Header, error) {
+func (s *_signer) SignHTTP(ctx context.Context, payload []byte) (http.Header, error) {
 	slog.Debug("signing http request", "payload", string(payload))
-	req, err := http.NewRequest("POST", s.url, bytes.NewBuffer(payload))
+	url, err := s.signingURL(false)
+	if err != nil {
+		slog.Error("error creating signing url", "error", err)
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
 		slog.Error("error creating signing request", "error", err)
 		return nil, err
 	}
-	switch signer := s.sdkSigner.(type) {
-	case *sdkv1_v4.Signer:
-		slog.Debug("signing request using sdk v1")
-		_, err = signer.Sign(req, bytes.NewReader(payload), "appsync", s.region, time.Now())
-		if err != nil {
-			slog.Error("error signing request using sdk v1", "error", err)
-			return nil, err
-		}
-	case *sdkv2_v4.Signer:
-		slog.Debug("signing request using sdk v2")
-		hash := sha256.Sum256(payload)
-		if err := signer.SignHTTP(context.TODO(), *s.creds, req, hex.EncodeToString(hash[:]), "appsync", s.region, time.Now()); err != nil {
-			slog.Error("error signing request using sdk v2", "error", err)
-			return nil, err
-		}
-	default:
-		return http.Header{}, errors.New("unsupported signer")
+	if err := s.sign(ctx, req, payload, s.now()); err != nil {
+		slog.Error("error signing request", "error", err)
+		return nil, err
+	}
+	if len(s.host) != 0 {
+		req.Header.Set("Host", req.Host)
 	}
 	return req.Header, nil
 }
 
-func (s *_signer) signWS(payload []byte) (map[string]string, error) {
-	slog.Debug("signing ws", "payload", string(payload))
-	url := s.url
-	if bytes.Equal(payload, []byte("{}")) {
-		url = url + "/connect"
+func (s *_signer) SignWebSocket(ctx context.Context, payload []byte, connect bool) (map[string]string, error) {
+	slog.Debug("signing ws", "payload", string(payload), "connect", connect)
+	url, err := s.signingURL(connect)
+	if err != nil {
+		slog.Error("error creating signing url", "error", err)
+		return nil, err
 	}
 	slog.Debug("signing ws url", "url", url)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
 		slog.Error("error creating request", "error", err)
 		return nil, err
@@ -74,46 +200,53 @@ func (s *_signer) signWS(payload []byte) (map[string]string, error) {
 	req.Header.Add("content-encoding", "amz-1.0")
 	req.Header.Add("content-type", "application/json; charset=UTF-8")
 
-	switch signer := s.sdkSigner.(type) {
-	case *sdkv1_v4.Signer:
-		slog.Debug("signing ws using sdk v1")
-		_, err = signer.Sign(req, bytes.NewReader(payload), "appsync", s.region, time.Now())
-		if err != nil {
-			slog.Error("error signing request", "error", err)
-			return nil, err
-		}
-		return map[string]string{
-			"accept":               req.Header.Get("accept"),
-			"content-encoding":     req.Header.Get("content-encoding"),
-			"content-type":         req.Header.Get("content-type"),
-			"host":                 req.Host,
-			"x-amz-date":           req.Header.Get("x-amz-date"),
-			"Authorization":        req.Header.Get("Authorization"),
-			"X-Amz-Security-Token": req.Header.Get("X-Amz-Security-Token"),
-		}, nil
-	case *sdkv2_v4.Signer:
-		slog.Debug("signing ws using sdk v2")
-		hash := sha256.Sum256(payload)
-		if err := signer.SignHTTP(context.TODO(), *s.creds, req, hex.EncodeToString(hash[:]), "appsync", s.region, time.Now()); err != nil {
-			slog.Error("error signing request", "error", err)
-			return nil, err
-		}
+	if err := s.sign(ctx, req, payload, s.now()); err != nil {
+		slog.Error("error signing request", "error", err)
+		return nil, err
+	}
 
-		headers := map[string]string{
-			"accept":           req.Header.Get("accept"),
-			"content-encoding": req.Header.Get("content-encoding"),
-			"content-type":     req.Header.Get("content-type"),
-			"content-length":   strconv.FormatInt(req.ContentLength, 10),
-			"host":             req.Host,
-			"x-amz-date":       req.Header.Get("x-amz-date"),
-			"Authorization":    req.Header.Get("Authorization"),
-		}
-		token := req.Header.Get("X-Amz-Security-Token")
-		if token != "" {
-			headers["X-Amz-Security-Token"] = token
-		}
-		slog.Debug("signed ws headers", "headers", headers)
-		return headers, nil
+	headers := map[string]string{
+		"accept":           req.Header.Get("accept"),
+		"content-encoding": req.Header.Get("content-encoding"),
+		"content-type":     req.Header.Get("content-type"),
+		"content-length":   strconv.FormatInt(req.ContentLength, 10),
+		"host":             req.Host,
+		"x-amz-date":       req.Header.Get("x-amz-date"),
+		"Authorization":    req.Header.Get("Authorization"),
+	}
+	token := req.Header.Get("X-Amz-Security-Token")
+	if token != "" {
+		headers["X-Amz-Security-Token"] = token
+	}
+	slog.Debug("signed ws headers", "headers", headers)
+	return headers, nil
+}
+
+// realtimeURL returns the realtime endpoint with the base64 encoded authorization header and payload in its query string.
+func realtimeURL(realtimeEndpoint string, header, payload []byte) string {
+	q := url.Values{}
+	q.Set("header", base64.StdEncoding.EncodeToString(header))
+	q.Set("payload", base64.StdEncoding.EncodeToString(payload))
+	sep := "?"
+	if strings.Contains(realtimeEndpoint, "?") {
+		sep = "&"
+	}
+	return realtimeEndpoint + sep + q.Encode()
+}
+
+// PresignRealtimeURL returns the realtime URL carrying the IAM authorization of the connection in its query string,
+// for websocket clients which can't sign the connection themselves. The connection is signed for the host of graphqlURL,
+// or for signingHost when it is not empty, such as the API host behind a VPC endpoint.
+func PresignRealtimeURL(realtimeEndpoint, graphqlURL, signingHost, region string, signer *sdkv2_v4.Signer, creds aws.Credentials) (string, error) {
+	s := NewSDKV2RequestSigner(signer, creds, region, graphqlURL, WithSigningHost(signingHost))
+	payload := []byte("{}")
+	headers, err := s.SignWebSocket(context.Background(), payload, true)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(headers)
+	if err != nil {
+		return "", err
 	}
-	return map[string]string{}, errors.New("unsupported signer")
+	return realtimeURL(realtimeEndpoint, header, payload), nil
 }
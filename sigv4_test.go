@@ -0,0 +1,414 @@
+package appsync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sdkv2_v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+var testCredentials = aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var testSigningTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// checkGolden compares v marshalled as indented JSON with the golden file testdata/name, updating it with -update.
+func checkGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch\nwant: %s\ngot: %s", name, want, got)
+	}
+}
+
+func TestSigner_golden(t *testing.T) {
+	s := NewSDKV2RequestSigner(sdkv2_v4.NewSigner(), testCredentials, "us-east-1",
+		"https://xxx.appsync-api.us-east-1.amazonaws.com/graphql", WithSigningClock(func() time.Time { return testSigningTime }))
+	request := []byte(`{"query":"subscription { onMessage { message } }"}`)
+
+	header, err := s.SignHTTP(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "sigv4_http.golden", header)
+
+	connect, err := s.SignWebSocket(context.Background(), []byte("{}"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "sigv4_ws_connect.golden", connect)
+
+	start, err := s.SignWebSocket(context.Background(), request, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "sigv4_ws_start.golden", start)
+}
+
+func TestSignatureServerTime(t *testing.T) {
+	errs := func(e ...interface{}) *graphql.Response { return &graphql.Response{Errors: &e} }
+	tests := []struct {
+		name     string
+		response *graphql.Response
+		want     time.Time
+		wantOK   bool
+	}{
+		{
+			name: "signature expired",
+			response: errs("Forbidden", map[string]interface{}{
+				"errorType": "InvalidSignatureException",
+				"message":   "Signature expired: 20240102T030405Z is now earlier than 20240102T031405Z (20240102T031905Z - 5 min.)",
+			}),
+			want:   time.Date(2024, 1, 2, 3, 19, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name: "signature not yet current",
+			response: errs(map[string]interface{}{
+				"message": "Signature not yet current: 20240102T030405Z is still later than 20240102T025405Z (20240102T024905Z + 5 min.)",
+			}),
+			want:   time.Date(2024, 1, 2, 2, 49, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:     "other error",
+			response: errs(map[string]interface{}{"errorType": "UnauthorizedException", "message": "You are not authorized."}),
+		},
+		{
+			name:     "no errors",
+			response: &graphql.Response{},
+		},
+		{
+			name: "nil",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := signatureServerTime(tt.response)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("want: %v, %t, got: %v, %t", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+// skewedRequestSigner is a custom RequestSigner dating its signatures by the testing time and its corrected skew.
+type skewedRequestSigner struct {
+	mu   sync.Mutex
+	skew time.Duration
+}
+
+func (s *skewedRequestSigner) SignHTTP(ctx context.Context, payload []byte) (http.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return http.Header{"X-Amz-Date": []string{testSigningTime.Add(s.skew).Format("20060102T150405Z")}}, nil
+}
+
+func (s *skewedRequestSigner) SignWebSocket(ctx context.Context, payload []byte, connect bool) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (s *skewedRequestSigner) CorrectClockSkew(serverTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skew = serverTime.Sub(testSigningTime)
+}
+
+func TestClient_clockSkew(t *testing.T) {
+	serverTime := testSigningTime.Add(15 * time.Minute)
+	var mu sync.Mutex
+	dates := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		date := r.Header.Get("X-Amz-Date")
+		mu.Lock()
+		dates = append(dates, date)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if date != serverTime.Format("20060102T150405Z") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"errors": [{"errorType": "InvalidSignatureException", "message": "Signature expired: %s is now earlier than %s (%s - 5 min.)"}]}`,
+				date, serverTime.Add(-5*time.Minute).Format("20060102T150405Z"), serverTime.Format("20060102T150405Z"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"message": "hello"}}`))
+	}))
+	defer server.Close()
+
+	signers := map[string]func() RequestSigner{
+		"sdk v2": func() RequestSigner {
+			return NewSDKV2RequestSigner(sdkv2_v4.NewSigner(), testCredentials, "us-east-1", server.URL,
+				WithSigningClock(func() time.Time { return testSigningTime }))
+		},
+		"custom": func() RequestSigner { return &skewedRequestSigner{} },
+	}
+	posts := map[string]func(c *Client) (*graphql.Response, error){
+		"Post": func(c *Client) (*graphql.Response, error) {
+			return c.Post(graphql.PostRequest{Query: "query { message }"})
+		},
+		"PostAsync": func(c *Client) (*graphql.Response, error) {
+			type result struct {
+				response *graphql.Response
+				err      error
+			}
+			ch := make(chan result, 1)
+			if _, err := c.PostAsync(graphql.PostRequest{Query: "query { message }"}, func(r *graphql.Response, err error) {
+				ch <- result{r, err}
+			}); err != nil {
+				return nil, err
+			}
+			select {
+			case r := <-ch:
+				return r.response, r.err
+			case <-time.After(5 * time.Second):
+				return nil, errors.New("timed out waiting for the callback")
+			}
+		},
+	}
+	for signerName, signer := range signers {
+		for postName, post := range posts {
+			t.Run(signerName+" "+postName, func(t *testing.T) {
+				mu.Lock()
+				dates = dates[:0]
+				mu.Unlock()
+				client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)), WithRequestSigner(signer()))
+				response, err := post(client)
+				if err != nil {
+					t.Fatal(err)
+				}
+				mu.Lock()
+				requests := len(dates)
+				mu.Unlock()
+				if *response.StatusCode != http.StatusOK || requests != 2 {
+					t.Errorf("got: %d, %d requests", *response.StatusCode, requests)
+				}
+				if _, err := post(client); err != nil {
+					t.Fatal(err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if len(dates) != 3 {
+					t.Errorf("the corrected clock should be kept: %v", dates)
+				}
+			})
+		}
+	}
+
+	// Signers which cannot correct their clock are not retried.
+	dates = dates[:0]
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)), WithRequestSigner(fakeRequestSigner{}))
+	response, _ := client.Post(graphql.PostRequest{Query: "query { message }"})
+	if response == nil || *response.StatusCode != http.StatusForbidden || len(dates) != 1 {
+		t.Errorf("got: %v, %v", response, dates)
+	}
+}
+
+func TestSigner_signingURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		host        string
+		wantGraphQL string
+		wantConnect string
+	}{
+		{
+			name:        "graphql endpoint",
+			url:         "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql",
+			wantGraphQL: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql",
+			wantConnect: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql/connect",
+		},
+		{
+			name:        "realtime endpoint",
+			url:         "wss://xxx.appsync-realtime-api.us-east-1.amazonaws.com/graphql",
+			wantGraphQL: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql",
+			wantConnect: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql/connect",
+		},
+		{
+			name:        "custom domain realtime endpoint",
+			url:         "wss://api.example.com/graphql/realtime",
+			wantGraphQL: "https://api.example.com/graphql",
+			wantConnect: "https://api.example.com/graphql/connect",
+		},
+		{
+			name:        "trailing slash and query",
+			url:         "https://api.example.com/graphql/?x=1",
+			wantGraphQL: "https://api.example.com/graphql",
+			wantConnect: "https://api.example.com/graphql/connect",
+		},
+		{
+			name:        "host without path",
+			url:         "https://api.example.com",
+			wantGraphQL: "https://api.example.com/graphql",
+			wantConnect: "https://api.example.com/graphql/connect",
+		},
+		{
+			name:        "already connect",
+			url:         "https://api.example.com/graphql/connect",
+			wantGraphQL: "https://api.example.com/graphql",
+			wantConnect: "https://api.example.com/graphql/connect",
+		},
+		{
+			name:        "vpc endpoint with signing host",
+			url:         "https://vpce-0123-abcd.appsync-api.us-east-1.vpce.amazonaws.com/graphql",
+			host:        "xxx.appsync-api.us-east-1.amazonaws.com",
+			wantGraphQL: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql",
+			wantConnect: "https://xxx.appsync-api.us-east-1.amazonaws.com/graphql/connect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSDKV2RequestSigner(sdkv2_v4.NewSigner(), testCredentials, "us-east-1", tt.url).(*_signer)
+			s.host = tt.host
+			if got, err := s.signingURL(false); err != nil || got != tt.wantGraphQL {
+				t.Errorf("want: %s, got: %s, %v", tt.wantGraphQL, got, err)
+			}
+			if got, err := s.signingURL(true); err != nil || got != tt.wantConnect {
+				t.Errorf("want: %s, got: %s, %v", tt.wantConnect, got, err)
+			}
+		})
+	}
+}
+
+func TestSigner_signingHost(t *testing.T) {
+	s := NewSDKV2RequestSigner(sdkv2_v4.NewSigner(), testCredentials, "us-east-1", "https://vpce-0123.appsync-api.us-east-1.vpce.amazonaws.com/graphql",
+		WithSigningHost("xxx.appsync-api.us-east-1.amazonaws.com")).(*_signer)
+	headers, err := s.SignWebSocket(context.Background(), []byte("{}"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers["host"] != "xxx.appsync-api.us-east-1.amazonaws.com" || headers["X-Amz-Security-Token"] != "TOKEN" {
+		t.Errorf("got: %v", headers)
+	}
+
+	header, err := s.SignHTTP(context.Background(), []byte(`{"query":"query { message }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Host") != "xxx.appsync-api.us-east-1.amazonaws.com" || !strings.Contains(header.Get("Authorization"), "host;") {
+		t.Errorf("got: %v", header)
+	}
+
+	s.host = ""
+	header, err = s.SignHTTP(context.Background(), []byte(`{"query":"query { message }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(header.Get("Host")) != 0 {
+		t.Errorf("the host should not be set without a signing host: %v", header)
+	}
+}
+
+func TestPresignRealtimeURL(t *testing.T) {
+	got, err := PresignRealtimeURL("wss://api.example.com/graphql/realtime", "https://api.example.com/graphql", "", "us-east-1",
+		sdkv2_v4.NewSigner(), testCredentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "api.example.com" || u.Path != "/graphql/realtime" {
+		t.Errorf("got: %s", got)
+	}
+	payload, err := base64.StdEncoding.DecodeString(u.Query().Get("payload"))
+	if err != nil || string(payload) != "{}" {
+		t.Errorf("payload: %s, %v", payload, err)
+	}
+	b, err := base64.StdEncoding.DecodeString(u.Query().Get("header"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := map[string]string{}
+	if err := json.Unmarshal(b, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header["host"] != "api.example.com" || !strings.HasPrefix(header["Authorization"], "AWS4-HMAC-SHA256 ") {
+		t.Errorf("header: %v", header)
+	}
+}
+
+func TestNewRequestSigner(t *testing.T) {
+	urls := []string{}
+	s := NewRequestSigner(func(ctx context.Context, req *http.Request, payload []byte, signTime time.Time) error {
+		urls = append(urls, req.URL.String())
+		if string(payload) == "fail" {
+			return errors.New("signing failed")
+		}
+		req.Header.Set("Authorization", "signed")
+		return nil
+	}, "wss://api.example.com/graphql/realtime")
+
+	headers, err := s.SignWebSocket(context.Background(), []byte("{}"), true)
+	if err != nil || headers["Authorization"] != "signed" || headers["host"] != "api.example.com" {
+		t.Errorf("got: %v, %v", headers, err)
+	}
+	// A subscription is signed for the GraphQL path whatever its payload.
+	if _, err := s.SignWebSocket(context.Background(), []byte("{}"), false); err != nil {
+		t.Error(err)
+	}
+	header, err := s.SignHTTP(context.Background(), []byte(`{}{}`))
+	if err != nil || header.Get("Authorization") != "signed" {
+		t.Errorf("got: %v, %v", header, err)
+	}
+	if _, err := s.SignWebSocket(context.Background(), []byte("fail"), false); err == nil {
+		t.Error("the signing error should be returned")
+	}
+	want := "https://api.example.com/graphql/connect,https://api.example.com/graphql,https://api.example.com/graphql,https://api.example.com/graphql"
+	if strings.Join(urls, ",") != want {
+		t.Errorf("want: %s, got: %v", want, urls)
+	}
+}
+
+type fakeRequestSigner struct{}
+
+func (fakeRequestSigner) SignHTTP(ctx context.Context, payload []byte) (http.Header, error) {
+	return http.Header{"Authorization": []string{"fake"}}, nil
+}
+
+func (fakeRequestSigner) SignWebSocket(ctx context.Context, payload []byte, connect bool) (map[string]string, error) {
+	return map[string]string{"Authorization": "fake"}, nil
+}
+
+func TestWithRequestSigner(t *testing.T) {
+	c := NewClient(&testGraphQLAPI{}, WithRequestSigner(fakeRequestSigner{}))
+	header, err := c.setupHeaders(graphql.PostRequest{Query: "query { message }"})
+	if err != nil || header.Get("Authorization") != "fake" {
+		t.Errorf("got: %v, %v", header, err)
+	}
+
+	p := NewPureWebSocketSubscriber(realtimeEndpoint, request, onReceive, onConnectionLost, WithWebSocketRequestSigner(fakeRequestSigner{}))
+	headers, err := p.setupHeaders([]byte("{}"), true)
+	if err != nil || headers["Authorization"] != "fake" {
+		t.Errorf("got: %v, %v", headers, err)
+	}
+}
@@ -2,9 +2,17 @@ package appsync
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
@@ -15,68 +23,195 @@ import (
 
 // Subscriber has MQTT connections and subscription information.
 type Subscriber struct {
-	clientID         string
-	topic            string
-	url              string
-	callback         func(response *graphql.Response)
+	mu          sync.RWMutex
+	connections []*mqttConnection
+	names       map[string][]string
+	expireTime  time.Time
+
+	handler          func(name string, response *graphql.Response)
 	onConnectionLost func(err error)
+	refresh          func() (*Extensions, error)
 	started          rwBool
+	reconnecting     atomic.Bool
+	ctx              context.Context
+	cancel           context.CancelFunc
+	status           subscriberStatus
+
+	qos              byte
+	keepAlive        time.Duration
+	cleanSession     bool
+	connectTimeout   time.Duration
+	subscribeTimeout time.Duration
+	quiesce          uint
+	tlsConfig        *tls.Config
+	dialer           *net.Dialer
+	proxy            func(*http.Request) (*url.URL, error)
+}
+
+type mqttConnection struct {
+	clientID string
+	url      string
+	topics   []string
 
 	mqtt MQTT.Client
 }
 
 const (
-	quiesce = 100
+	defaultQuiesce          = 100
+	defaultConnectTimeout   = 5 * time.Minute
+	defaultSubscribeTimeout = 60 * time.Second
+
+	// expiryMargin is how long before the expiry the connections are renewed.
+	expiryMargin = time.Minute
+	// reconnectTimeout is how long lost or expiring connections are renewed before giving up.
+	reconnectTimeout = 5 * time.Minute
 )
 
-// NewSubscriber returns a new Subscriber instance.
-func NewSubscriber(extensions Extensions, callback func(response *graphql.Response), onConnectionLost func(err error)) *Subscriber {
+var (
+	// ErrNoMqttConnections is returned when the extensions have no MQTT connections.
+	ErrNoMqttConnections = errors.New("there are no mqtt connections")
+	// ErrNoNewSubscriptions is returned when the extensions have no new subscriptions.
+	ErrNoNewSubscriptions = errors.New("there are no new subscriptions")
+	// ErrTopicMismatch is returned when no MQTT connection lists the topic of a new subscription.
+	ErrTopicMismatch = errors.New("there is no mqtt connection for the topic")
+)
+
+// NewSubscriber returns a new Subscriber instance which calls callback with the messages of every new subscription.
+func NewSubscriber(extensions Extensions, callback func(response *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
+	return newSubscriber(extensions, func(_ string, response *graphql.Response) { callback(response) }, onConnectionLost, opts...)
+}
+
+// NewMultiSubscriber returns a new Subscriber instance which routes the messages of each new subscription
+// to the handler registered with its subscription name.
+func NewMultiSubscriber(extensions Extensions, handlers map[string]func(response *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
+	return newSubscriber(extensions, multiHandler(extensions, handlers), onConnectionLost, opts...)
+}
+
+// NewSubscriber posts the subscription request and returns a new Subscriber instance for its extensions.
+// The subscriber posts the request again to renew the connections before they expire or after they are lost.
+func (c *Client) NewSubscriber(request graphql.PostRequest, callback func(response *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
+	return c.newSubscriber(request, func(Extensions) func(string, *graphql.Response) {
+		return func(_ string, response *graphql.Response) { callback(response) }
+	}, onConnectionLost, opts...)
+}
+
+// NewMultiSubscriber posts the subscription request and returns a new Subscriber instance routing
+// the messages of each new subscription to the handler registered with its subscription name.
+// The subscriber posts the request again to renew the connections before they expire or after they are lost.
+func (c *Client) NewMultiSubscriber(request graphql.PostRequest, handlers map[string]func(response *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
+	return c.newSubscriber(request, func(ext Extensions) func(string, *graphql.Response) {
+		return multiHandler(ext, handlers)
+	}, onConnectionLost, opts...)
+}
+
+func (c *Client) newSubscriber(request graphql.PostRequest, handler func(Extensions) func(string, *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
+	refresh := func() (*Extensions, error) {
+		response, err := c.Post(request)
+		if err != nil {
+			return nil, err
+		}
+		return NewExtensions(response)
+	}
+	ext, err := refresh()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSubscriber(*ext, handler(*ext), onConnectionLost, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.refresh = refresh
+	return s, nil
+}
+
+func multiHandler(extensions Extensions, handlers map[string]func(response *graphql.Response)) func(string, *graphql.Response) {
+	for name := range extensions.Subscription.NewSubscriptions {
+		if _, ok := handlers[name]; !ok {
+			slog.Warn("there is no handler for subscription", "name", name)
+		}
+	}
+	return func(name string, response *graphql.Response) {
+		h, ok := handlers[name]
+		if !ok {
+			return
+		}
+		h(response)
+	}
+}
+
+func newSubscriber(extensions Extensions, handler func(name string, response *graphql.Response), onConnectionLost func(err error), opts ...SubscriberOption) (*Subscriber, error) {
 	slog.Debug("creating new subscriber", "extensions", extensions)
+	connections, names, expireTime, err := parseExtensions(extensions)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscriber{
+		connections:      connections,
+		names:            names,
+		expireTime:       expireTime,
+		handler:          handler,
+		onConnectionLost: onConnectionLost,
+		started:          rwBool{b: false},
+		cleanSession:     true,
+		connectTimeout:   defaultConnectTimeout,
+		subscribeTimeout: defaultSubscribeTimeout,
+		quiesce:          defaultQuiesce,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// parseExtensions returns the connections subscribing to every new subscription topic,
+// the subscription names by topic and the earliest expiry of the subscriptions.
+func parseExtensions(extensions Extensions) ([]*mqttConnection, map[string][]string, time.Time, error) {
 	if len(extensions.Subscription.MqttConnections) == 0 {
-		slog.Warn("There is no mqtt connections.", "extensions", extensions)
-		return nil
+		return nil, nil, time.Time{}, ErrNoMqttConnections
+	}
+	if len(extensions.Subscription.NewSubscriptions) == 0 {
+		return nil, nil, time.Time{}, ErrNoNewSubscriptions
 	}
 
-	topic := func() string {
-		if len(extensions.Subscription.NewSubscriptions) > 1 {
-			slog.Warn("Multiple subscriptions are currently not supported.", "extensions", extensions)
-			return ""
+	names := map[string][]string{}
+	expireTime := time.Time{}
+	for name, s := range extensions.Subscription.NewSubscriptions {
+		slog.Debug("topics", "name", name, "topic", s.Topic, "expireTime", s.ExpireTime)
+		if len(s.Topic) == 0 {
+			return nil, nil, time.Time{}, fmt.Errorf("%w: subscription %s has no topic", ErrTopicMismatch, name)
 		}
-		for _, v := range extensions.Subscription.NewSubscriptions {
-			slog.Debug("topics", "topic", v.Topic)
-			return v.Topic
+		names[s.Topic] = append(names[s.Topic], name)
+		if !s.ExpireTime.IsZero() && (expireTime.IsZero() || s.ExpireTime.Before(expireTime)) {
+			expireTime = s.ExpireTime.Time
 		}
-		return ""
-	}()
-	if len(topic) == 0 {
-		slog.Warn("There are no new subscriptions.", "extensions", extensions)
-		return nil
 	}
 
-	index := func() int {
-		for i, c := range extensions.Subscription.MqttConnections {
-			for _, t := range c.Topics {
-				if t == topic {
-					return i
-				}
+	connections := []*mqttConnection{}
+	subscribed := map[string]bool{}
+	for _, c := range extensions.Subscription.MqttConnections {
+		topics := []string{}
+		for _, t := range c.Topics {
+			if _, ok := names[t]; !ok || subscribed[t] {
+				continue
 			}
+			subscribed[t] = true
+			topics = append(topics, t)
 		}
-		return -1
-	}()
-	if index < 0 {
-		slog.Warn("There are no new subscriptions.", "extensions", extensions)
-		return nil
+		if len(topics) == 0 {
+			continue
+		}
+		connections = append(connections, &mqttConnection{
+			clientID: c.Client,
+			url:      c.URL,
+			topics:   topics,
+		})
 	}
-
-	mqttConnection := extensions.Subscription.MqttConnections[index]
-	return &Subscriber{
-		clientID:         mqttConnection.Client,
-		url:              mqttConnection.URL,
-		topic:            topic,
-		callback:         callback,
-		onConnectionLost: onConnectionLost,
-		started:          rwBool{b: false},
+	for topic, n := range names {
+		if !subscribed[topic] {
+			return nil, nil, time.Time{}, fmt.Errorf("%w: topic %s of subscription %s", ErrTopicMismatch, topic, strings.Join(n, ", "))
+		}
 	}
+	return connections, names, expireTime, nil
 }
 
 type rwBool struct {
@@ -96,85 +231,324 @@ func (r *rwBool) store(b bool) {
 	r.b = b
 }
 
-// Start starts a new subscription.
+// ExpireTime returns the earliest expiry of the subscriptions. It is zero when AppSync provides none.
+func (s *Subscriber) ExpireTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.expireTime
+}
+
+// Start starts the subscriptions on every MQTT connection.
+// Lost connections are reconnected, and the connections of subscribers created by Client
+// are renewed with fresh extensions before they expire.
 func (s *Subscriber) Start() error {
-	slog.Debug("starting new subscriber", "clientID", s.clientID, "url", s.url, "topic", s.topic)
+	return s.StartContext(context.Background())
+}
+
+// StartContext is like Start but aborts connecting and subscribing when ctx is done.
+// Reconnecting and renewing keep the values of ctx but are only canceled by Stop.
+func (s *Subscriber) StartContext(ctx context.Context) error {
+	if err := s.status.begin(); err != nil {
+		return err
+	}
+	s.started.store(true)
+	s.mu.Lock()
+	s.ctx, s.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	runCtx := s.ctx
+	connections := s.connections
+	s.mu.Unlock()
+	if err := s.connect(ctx, connections); err != nil {
+		s.teardown()
+		s.status.set(SubscriberFailed, err)
+		return err
+	}
+	if s.refresh != nil {
+		go s.watchExpiry(runCtx)
+	}
+	s.status.set(SubscriberStarted, nil)
+	return nil
+}
+
+// State returns the current state of the subscriber.
+func (s *Subscriber) State() SubscriberState {
+	state, _ := s.status.get()
+	return state
+}
+
+// Err returns the error which failed starting or reconnecting last, or nil.
+func (s *Subscriber) Err() error {
+	_, err := s.status.get()
+	return err
+}
+
+func (s *Subscriber) connect(ctx context.Context, connections []*mqttConnection) error {
+	for _, c := range connections {
+		if err := s.start(ctx, c); err != nil {
+			s.disconnect(connections)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Subscriber) start(ctx context.Context, conn *mqttConnection) error {
+	slog.Debug("starting new subscriber", "clientID", conn.clientID, "url", conn.url, "topics", conn.topics)
 	opts := MQTT.NewClientOptions().
-		AddBroker(s.url).
-		SetClientID(s.clientID).
+		AddBroker(conn.url).
+		SetClientID(conn.clientID).
 		SetAutoReconnect(false).
+		SetCleanSession(s.cleanSession).
 		SetConnectionLostHandler(func(c MQTT.Client, err error) {
 			if !s.started.load() {
 				return
 			}
-			s.onConnectionLost(err)
+			slog.Warn("mqtt connection lost", "clientID", conn.clientID, "error", err)
+			go s.reconnect(err)
 		})
+	if s.tlsConfig != nil {
+		opts.SetTLSConfig(s.tlsConfig)
+	}
+	if s.keepAlive > 0 {
+		opts.SetKeepAlive(s.keepAlive)
+	}
+	// An attempt never outlasts the window connecting is retried in.
+	opts.SetConnectTimeout(min(opts.ConnectTimeout, s.connectTimeout))
+	if s.dialer != nil {
+		opts.SetCustomOpenConnectionFn(mqttWebsocketOpener(s.dialer, s.proxy))
+	} else if s.proxy != nil {
+		opts.SetWebsocketOptions(&MQTT.WebsocketOptions{Proxy: s.proxy})
+	}
 
 	ch := make(chan error, 1)
-	defer close(ch)
-
 	opts.OnConnect = func(c MQTT.Client) {
-		mqttCallback := func(client MQTT.Client, msg MQTT.Message) {
-			if !s.started.load() {
-				return
-			}
-			r := new(graphql.Response)
-			if err := json.Unmarshal(msg.Payload(), r); err != nil {
-				slog.Error("unable to unmarshal mqtt message", "error", err, "message", string(msg.Payload()))
-				return
-			}
-			s.callback(r)
+		filters := map[string]byte{}
+		for _, t := range conn.topics {
+			filters[t] = s.qos
 		}
-
 		subscribe := func() (string, error) {
-			if token := c.Subscribe(s.topic, 0, mqttCallback); token.Wait() && token.Error() != nil {
-				slog.Warn("unable to subscribe to topic", "topic", s.topic, "error", token.Error())
-				return "", token.Error()
+			if err := wait(ctx, c.SubscribeMultiple(filters, s.route)); err != nil {
+				slog.Warn("unable to subscribe to topics", "topics", conn.topics, "error", err)
+				return "", err
 			}
-			s.started.store(true)
 			return "", nil
 		}
 
-		subscribeTimeout := 60 * time.Second
-		_, err := backoff.Retry(context.TODO(), subscribe,
+		_, err := backoff.Retry(ctx, subscribe,
 			backoff.WithBackOff(backoff.NewExponentialBackOff()),
-			backoff.WithMaxElapsedTime(subscribeTimeout))
-		ch <- err
+			backoff.WithMaxElapsedTime(s.subscribeTimeout))
+		select {
+		case ch <- err:
+		default:
+		}
 	}
 
 	mqtt := MQTT.NewClient(opts)
 	connect := func() (string, error) {
-		if token := mqtt.Connect(); token.Wait() && token.Error() != nil {
-			return "", token.Error()
+		if err := wait(ctx, mqtt.Connect()); err != nil {
+			return "", err
 		}
-		s.mqtt = mqtt
+		s.mu.Lock()
+		conn.mqtt = mqtt
+		s.mu.Unlock()
 		return "", nil
 	}
 
-	connectionTimeout := 5 * time.Minute
-	_, err := backoff.Retry(context.TODO(), connect,
+	_, err := backoff.Retry(ctx, connect,
 		backoff.WithBackOff(backoff.NewExponentialBackOff()),
-		backoff.WithMaxElapsedTime(connectionTimeout))
+		backoff.WithMaxElapsedTime(s.connectTimeout))
 	if err != nil {
 		slog.Warn("unable to connect to mqtt on retry", "error", err)
+		mqtt.Disconnect(0)
 		return err
 	}
 
-	return <-ch
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// Stop ends the subscription.
-func (s *Subscriber) Stop() {
-	slog.Debug("stopping subscriber", "topic", s.topic)
-	if s.mqtt == nil {
+// wait waits for the token to complete or ctx to be done.
+func wait(ctx context.Context, token MQTT.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Subscriber) watchExpiry(ctx context.Context) {
+	for {
+		expireTime := s.ExpireTime()
+		if expireTime.IsZero() {
+			return
+		}
+		timer := time.NewTimer(max(time.Until(expireTime)-expiryMargin, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		slog.Debug("renewing subscriptions before expiry", "expireTime", expireTime)
+		s.reconnect(nil)
+		if !s.ExpireTime().After(expireTime) {
+			// The renewal gave up or AppSync did not extend the expiry.
+			return
+		}
+	}
+}
+
+// reconnect renews the connections, with fresh extensions when available, and calls onConnectionLost when giving up.
+func (s *Subscriber) reconnect(cause error) {
+	if !s.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.reconnecting.Store(false)
+	s.status.set(SubscriberReconnecting, cause)
+
+	ctx := s.context()
+	renew := func() (string, error) {
+		if !s.started.load() {
+			return "", backoff.Permanent(ErrSubscriberStopped)
+		}
+		return "", s.renew(ctx)
+	}
+	_, err := backoff.Retry(ctx, renew,
+		backoff.WithBackOff(backoff.NewExponentialBackOff()),
+		backoff.WithMaxElapsedTime(reconnectTimeout))
+	if !s.started.load() {
+		return
+	}
+	if err == nil {
+		s.status.set(SubscriberStarted, nil)
+		return
+	}
+	slog.Warn("unable to reconnect subscriber", "error", err)
+	s.status.set(SubscriberFailed, err)
+	if cause == nil {
+		cause = err
+	}
+	s.onConnectionLost(cause)
+}
+
+// context returns the context saved by StartContext, which is canceled by Stop.
+func (s *Subscriber) context() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *Subscriber) renew(ctx context.Context) error {
+	s.mu.RLock()
+	connections := make([]*mqttConnection, 0, len(s.connections))
+	for _, c := range s.connections {
+		connections = append(connections, &mqttConnection{clientID: c.clientID, url: c.url, topics: c.topics})
+	}
+	names := s.names
+	expireTime := s.expireTime
+	s.mu.RUnlock()
+
+	if s.refresh != nil {
+		ext, err := s.refresh()
+		if err != nil {
+			slog.Warn("unable to refresh subscription", "error", err)
+			return err
+		}
+		connections, names, expireTime, err = parseExtensions(*ext)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+	}
+
+	// The lock is not held while connecting, since routing the messages of the new connections needs it.
+	s.mu.Lock()
+	old := s.connections
+	s.names = names
+	s.mu.Unlock()
+	s.disconnect(old)
+
+	if err := s.connect(ctx, connections); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.connections = connections
+	s.expireTime = expireTime
+	s.mu.Unlock()
+	if !s.started.load() {
+		s.disconnect(connections)
+	}
+	return nil
+}
+
+func (s *Subscriber) route(_ MQTT.Client, msg MQTT.Message) {
+	if !s.started.load() {
+		return
+	}
+	s.mu.RLock()
+	names, ok := s.names[msg.Topic()]
+	s.mu.RUnlock()
+	if !ok {
+		slog.Warn("unable to route mqtt message", "topic", msg.Topic())
 		return
 	}
+	r := new(graphql.Response)
+	if err := json.Unmarshal(msg.Payload(), r); err != nil {
+		slog.Error("unable to unmarshal mqtt message", "error", err, "message", string(msg.Payload()))
+		return
+	}
+	for _, name := range names {
+		s.handler(name, r)
+	}
+}
+
+// Stop ends the subscriptions and disconnects every MQTT connection.
+func (s *Subscriber) Stop() {
+	s.teardown()
+	s.status.stop()
+}
+
+// teardown cancels reconnecting and disconnects every MQTT connection.
+func (s *Subscriber) teardown() {
 	s.started.store(false)
-	defer func() {
-		s.mqtt.Disconnect(quiesce)
-		s.mqtt = nil
-	}()
-	if token := s.mqtt.Unsubscribe(s.topic); token.Wait() && token.Error() != nil {
-		slog.Warn("error in token", "topic", s.topic, "error", token.Error())
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	connections := s.connections
+	s.mu.Unlock()
+	s.disconnect(connections)
+}
+
+// StopContext is like Stop but returns ctx.Err() when ctx is done before the connections are closed.
+func (s *Subscriber) StopContext(ctx context.Context) error {
+	return stopContext(ctx, s.Stop)
+}
+
+// disconnect disconnects the clients of the connections. Each client is taken under the lock,
+// so that Stop and a concurrent renewal never disconnect the same client twice.
+func (s *Subscriber) disconnect(connections []*mqttConnection) {
+	for _, c := range connections {
+		slog.Debug("stopping subscriber", "topics", c.topics)
+		s.mu.Lock()
+		mqtt := c.mqtt
+		c.mqtt = nil
+		s.mu.Unlock()
+		if mqtt == nil {
+			continue
+		}
+		if mqtt.IsConnected() {
+			if token := mqtt.Unsubscribe(c.topics...); token.Wait() && token.Error() != nil {
+				slog.Warn("error in token", "topics", c.topics, "error", token.Error())
+			}
+		}
+		mqtt.Disconnect(s.quiesce)
 	}
 }
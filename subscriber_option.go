@@ -0,0 +1,76 @@
+package appsync
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SubscriberOption represents options for a Subscriber.
+type SubscriberOption func(*Subscriber)
+
+// WithMQTTQoS returns a SubscriberOption configured with the QoS level of the subscriptions.
+func WithMQTTQoS(qos byte) SubscriberOption {
+	return func(s *Subscriber) {
+		s.qos = qos
+	}
+}
+
+// WithMQTTKeepAlive returns a SubscriberOption configured with the keepalive interval of the MQTT connections.
+func WithMQTTKeepAlive(keepAlive time.Duration) SubscriberOption {
+	return func(s *Subscriber) {
+		s.keepAlive = keepAlive
+	}
+}
+
+// WithMQTTCleanSession returns a SubscriberOption configured with the clean session flag of the MQTT connections.
+func WithMQTTCleanSession(cleanSession bool) SubscriberOption {
+	return func(s *Subscriber) {
+		s.cleanSession = cleanSession
+	}
+}
+
+// WithMQTTConnectTimeout returns a SubscriberOption configured with how long connecting is retried.
+// Each attempt is bounded by it as well.
+func WithMQTTConnectTimeout(timeout time.Duration) SubscriberOption {
+	return func(s *Subscriber) {
+		s.connectTimeout = timeout
+	}
+}
+
+// WithMQTTSubscribeTimeout returns a SubscriberOption configured with how long subscribing is retried.
+func WithMQTTSubscribeTimeout(timeout time.Duration) SubscriberOption {
+	return func(s *Subscriber) {
+		s.subscribeTimeout = timeout
+	}
+}
+
+// WithMQTTQuiesce returns a SubscriberOption configured with how long Stop waits for in-flight work before disconnecting.
+func WithMQTTQuiesce(quiesce time.Duration) SubscriberOption {
+	return func(s *Subscriber) {
+		s.quiesce = uint(quiesce.Milliseconds())
+	}
+}
+
+// WithMQTTTLSConfig returns a SubscriberOption configured with the TLS config of the MQTT connections.
+func WithMQTTTLSConfig(config *tls.Config) SubscriberOption {
+	return func(s *Subscriber) {
+		s.tlsConfig = config
+	}
+}
+
+// WithMQTTDialer returns a SubscriberOption configured with the dialer of the MQTT websockets.
+func WithMQTTDialer(dialer *net.Dialer) SubscriberOption {
+	return func(s *Subscriber) {
+		s.dialer = dialer
+	}
+}
+
+// WithMQTTProxy returns a SubscriberOption configured with the proxy of the websockets, such as http.ProxyFromEnvironment.
+func WithMQTTProxy(proxy func(*http.Request) (*url.URL, error)) SubscriberOption {
+	return func(s *Subscriber) {
+		s.proxy = proxy
+	}
+}
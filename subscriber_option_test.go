@@ -0,0 +1,139 @@
+package appsync
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func TestSubscriberOptions(t *testing.T) {
+	ext := newTestExtensions(t, "ws://localhost")
+	tlsConfig := &tls.Config{ServerName: "example.com"}
+	dialer := &net.Dialer{Timeout: time.Second}
+
+	s, err := NewSubscriber(ext, func(*graphql.Response) {}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.qos != 0 || !s.cleanSession || s.connectTimeout != 5*time.Minute || s.subscribeTimeout != 60*time.Second || s.quiesce != 100 {
+		t.Errorf("unexpected defaults: %+v", s)
+	}
+
+	s, err = NewSubscriber(ext, func(*graphql.Response) {}, func(error) {},
+		WithMQTTQoS(1),
+		WithMQTTKeepAlive(10*time.Second),
+		WithMQTTCleanSession(false),
+		WithMQTTConnectTimeout(time.Minute),
+		WithMQTTSubscribeTimeout(10*time.Second),
+		WithMQTTQuiesce(250*time.Millisecond),
+		WithMQTTTLSConfig(tlsConfig),
+		WithMQTTDialer(dialer),
+		WithMQTTProxy(http.ProxyFromEnvironment),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.qos != 1 {
+		t.Errorf("qos: %d", s.qos)
+	}
+	if s.keepAlive != 10*time.Second {
+		t.Errorf("keepAlive: %v", s.keepAlive)
+	}
+	if s.cleanSession {
+		t.Error("cleanSession should be false")
+	}
+	if s.connectTimeout != time.Minute || s.subscribeTimeout != 10*time.Second {
+		t.Errorf("timeouts: %v, %v", s.connectTimeout, s.subscribeTimeout)
+	}
+	if s.quiesce != 250 {
+		t.Errorf("quiesce: %d", s.quiesce)
+	}
+	if s.tlsConfig != tlsConfig || s.dialer != dialer || s.proxy == nil {
+		t.Errorf("transport: %v, %v, %t", s.tlsConfig, s.dialer, s.proxy != nil)
+	}
+}
+
+func TestWithMQTTDialer(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	var dials atomic.Int32
+	dialer := &net.Dialer{Control: func(string, string, syscall.RawConn) error {
+		dials.Add(1)
+		return nil
+	}}
+	ch := make(chan *graphql.Response, 1)
+	ext := newTestExtensions(t, strings.Replace(server.URL, "http", "ws", 1))
+	s, err := NewSubscriber(ext, func(r *graphql.Response) { ch <- r }, func(err error) { t.Error(err) }, WithMQTTDialer(dialer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if dials.Load() == 0 {
+		t.Fatal("the websockets should be dialed with the dialer")
+	}
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	request := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).Var("message", "Hi, AppSync!").MustBuild()
+	if _, err := client.Post(request); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-ch:
+		data := new(string)
+		if err := r.DataAs(data); err != nil || *data != "Hi, AppSync!" {
+			t.Errorf("got: %v, %v", *data, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the echo subscription")
+	}
+}
+
+func TestWithMQTTConnectTimeout(t *testing.T) {
+	// The listener accepts connections but never answers the websocket handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	for name, opts := range map[string][]SubscriberOption{
+		"default dialer": {WithMQTTConnectTimeout(300 * time.Millisecond)},
+		"custom dialer":  {WithMQTTConnectTimeout(300 * time.Millisecond), WithMQTTDialer(&net.Dialer{})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ext := newTestExtensions(t, "ws://"+l.Addr().String())
+			s, err := NewSubscriber(ext, func(*graphql.Response) {}, func(error) {}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			if err := s.Start(); err == nil {
+				t.Fatal("the handshake should time out")
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("connecting should give up after the connect timeout, took %v", time.Since(start))
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package appsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func newTestExtensions(t *testing.T, url string) Extensions {
+	t.Helper()
+	ext := Extensions{}
+	raw := fmt.Sprintf(`{"subscription": {
+	"version": "1.0.0",
+	"mqttConnections": [
+		{"url": "%[1]s", "topics": ["echo", "unknown"], "client": "client-1"},
+		{"url": "%[1]s", "topics": ["other"], "client": "client-2"}
+	],
+	"newSubscriptions": {
+		"subscribeToEcho": {"topic": "echo", "expireTime": null},
+		"subscribeToOther": {"topic": "other", "expireTime": null}
+	}
+}}`, url)
+	if err := json.Unmarshal([]byte(raw), &ext); err != nil {
+		t.Fatal(err)
+	}
+	return ext
+}
+
+func TestNewSubscriber(t *testing.T) {
+	ext := newTestExtensions(t, "ws://localhost")
+	s, err := NewSubscriber(ext, func(*graphql.Response) {}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.connections) != 2 {
+		t.Fatalf("want: 2 connections, got: %d", len(s.connections))
+	}
+	if strings.Join(s.connections[0].topics, ",") != "echo" || strings.Join(s.connections[1].topics, ",") != "other" {
+		t.Errorf("got: %v, %v", s.connections[0].topics, s.connections[1].topics)
+	}
+	names := []string{}
+	for _, n := range s.names {
+		names = append(names, n...)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "subscribeToEcho,subscribeToOther" {
+		t.Errorf("got: %v", names)
+	}
+
+	noSubscriptions := newTestExtensions(t, "ws://localhost")
+	noSubscriptions.Subscription.NewSubscriptions = nil
+	mismatch := newTestExtensions(t, "ws://localhost")
+	mismatch.Subscription.NewSubscriptions["missing"] = Subscription{Topic: "missing"}
+	noTopic := newTestExtensions(t, "ws://localhost")
+	noTopic.Subscription.NewSubscriptions["missing"] = Subscription{}
+	tests := []struct {
+		name       string
+		extensions Extensions
+		want       error
+	}{
+		{name: "no mqtt connections", extensions: Extensions{}, want: ErrNoMqttConnections},
+		{name: "no new subscriptions", extensions: noSubscriptions, want: ErrNoNewSubscriptions},
+		{name: "topic mismatch", extensions: mismatch, want: ErrTopicMismatch},
+		{name: "no topic", extensions: noTopic, want: ErrTopicMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSubscriber(tt.extensions, func(*graphql.Response) {}, func(error) {})
+			if s != nil || !errors.Is(err, tt.want) {
+				t.Errorf("want: %v, got: %v, %v", tt.want, s, err)
+			}
+		})
+	}
+}
+
+func TestNewMultiSubscriber(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	echo := make(chan *graphql.Response, 1)
+	other := make(chan *graphql.Response, 1)
+	ext := newTestExtensions(t, strings.Replace(server.URL, "http", "ws", 1))
+	s, err := NewMultiSubscriber(ext, map[string]func(*graphql.Response){
+		"subscribeToEcho":  func(r *graphql.Response) { echo <- r },
+		"subscribeToOther": func(r *graphql.Response) { other <- r },
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if s.State() != SubscriberStarted {
+		t.Errorf("state: %s", s.State())
+	}
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	request := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).Var("message", "Hi, AppSync!").MustBuild()
+	if _, err := client.Post(request); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-echo:
+		data := new(string)
+		if err := r.DataAs(data); err != nil || *data != "Hi, AppSync!" {
+			t.Errorf("got: %v, %v", *data, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the echo subscription")
+	}
+	select {
+	case r := <-other:
+		t.Errorf("unexpected message: %v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_NewSubscriber(t *testing.T) {
+	delay := subscriptionDelay
+	subscriptionDelay = 0
+	defer func() { subscriptionDelay = delay }()
+
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	ch := make(chan *graphql.Response, 1)
+	s, err := client.NewSubscriber(graphql.PostRequest{Query: `subscription SubscribeToEcho() { subscribeToEcho }`},
+		func(r *graphql.Response) { ch <- r }, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID := s.connections[0].clientID
+
+	// An expired subscription is renewed with fresh extensions right after starting.
+	s.expireTime = time.Now()
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		renewed := s.connections[0].clientID != clientID && s.connections[0].mqtt != nil
+		s.mu.RUnlock()
+		if renewed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the renewal")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !s.ExpireTime().IsZero() {
+		t.Errorf("got: %v", s.ExpireTime())
+	}
+
+	// A lost connection is reconnected and resubscribed.
+	s.reconnect(errors.New("connection lost"))
+
+	request := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).Var("message", "Hi, AppSync!").MustBuild()
+	if _, err := client.Post(request); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-ch:
+		data := new(string)
+		if err := r.DataAs(data); err != nil || *data != "Hi, AppSync!" {
+			t.Errorf("got: %v, %v", *data, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the echo subscription")
+	}
+}
+
+func TestSubscriber_StopCancelsReconnect(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	ext := newTestExtensions(t, strings.Replace(server.URL, "http", "ws", 1))
+	s, err := NewSubscriber(ext, func(*graphql.Response) {}, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The renewed connections never come up, so only Stop ends reconnecting.
+	s.mu.Lock()
+	for _, c := range s.connections {
+		c.url = "ws://127.0.0.1:1"
+	}
+	s.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.reconnect(errors.New("connection lost"))
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop should cancel reconnecting")
+	}
+	if s.State() != SubscriberStopped {
+		t.Errorf("state: %s", s.State())
+	}
+}
+
+func TestSubscriber_StartContext(t *testing.T) {
+	ext := newTestExtensions(t, "ws://127.0.0.1:1")
+	s, err := NewSubscriber(ext, func(*graphql.Response) {}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := s.StartContext(ctx); err == nil {
+		t.Fatal("connecting to a closed port should fail")
+	}
+	if s.State() != SubscriberFailed || s.Err() == nil {
+		t.Errorf("got: %s, %v", s.State(), s.Err())
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("StartContext should be aborted by the context, took %v", time.Since(start))
+	}
+}
@@ -0,0 +1,141 @@
+// Package subscribertest provides an in-memory appsync.RealtimeSubscriber for unit tests.
+package subscribertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	appsync "github.com/sony/appsync-client-go"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// ErrNotStarted is returned when pushing to a Subscriber which is not started.
+var ErrNotStarted = errors.New("subscriber is not started")
+
+// Subscriber is an in-memory appsync.RealtimeSubscriber whose events are pushed by tests.
+type Subscriber struct {
+	onReceive        func(response *graphql.Response)
+	onConnectionLost func(err error)
+
+	mu       sync.Mutex
+	state    appsync.SubscriberState
+	err      error
+	startErr error
+	stopped  bool
+	starts   int
+	stops    int
+}
+
+var _ appsync.RealtimeSubscriber = (*Subscriber)(nil)
+
+// NewSubscriber returns a new Subscriber calling onReceive with the pushed responses
+// and onConnectionLost with the pushed connection losses.
+func NewSubscriber(onReceive func(response *graphql.Response), onConnectionLost func(err error)) *Subscriber {
+	return &Subscriber{onReceive: onReceive, onConnectionLost: onConnectionLost}
+}
+
+// FailStart makes the next starts fail with err. A nil err lets them succeed again.
+func (s *Subscriber) FailStart(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startErr = err
+}
+
+// Start starts the subscriber.
+func (s *Subscriber) Start() error {
+	return s.StartContext(context.Background())
+}
+
+// StartContext starts the subscriber unless ctx is done, or the subscriber is running or has been stopped.
+func (s *Subscriber) StartContext(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts++
+	if s.stopped {
+		return appsync.ErrSubscriberStopped
+	}
+	if s.state != appsync.SubscriberStopped && s.state != appsync.SubscriberFailed {
+		return appsync.ErrSubscriberStarted
+	}
+	err := ctx.Err()
+	if err == nil {
+		err = s.startErr
+	}
+	if err != nil {
+		s.state, s.err = appsync.SubscriberFailed, err
+		return err
+	}
+	s.state = appsync.SubscriberStarted
+	return nil
+}
+
+// Stop stops the subscriber for good.
+func (s *Subscriber) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stops++
+	s.stopped = true
+	s.state = appsync.SubscriberStopped
+}
+
+// StopContext stops the subscriber.
+func (s *Subscriber) StopContext(ctx context.Context) error {
+	s.Stop()
+	return ctx.Err()
+}
+
+// State returns the current state.
+func (s *Subscriber) State() appsync.SubscriberState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Err returns the error which failed the subscriber last, or nil.
+func (s *Subscriber) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Starts returns how many times the subscriber has been started.
+func (s *Subscriber) Starts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.starts
+}
+
+// Stops returns how many times the subscriber has been stopped.
+func (s *Subscriber) Stops() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stops
+}
+
+// Push delivers the response to onReceive as a received message.
+func (s *Subscriber) Push(response *graphql.Response) error {
+	if s.State() != appsync.SubscriberStarted {
+		return ErrNotStarted
+	}
+	s.onReceive(response)
+	return nil
+}
+
+// PushData delivers a response whose data has the value of the subscription field to onReceive.
+func (s *Subscriber) PushData(field string, value interface{}) error {
+	return s.Push(&graphql.Response{Data: map[string]interface{}{field: value}})
+}
+
+// LoseConnection fails the subscriber with err and calls onConnectionLost.
+func (s *Subscriber) LoseConnection(err error) error {
+	s.mu.Lock()
+	if s.state != appsync.SubscriberStarted {
+		s.mu.Unlock()
+		return ErrNotStarted
+	}
+	s.state, s.err = appsync.SubscriberFailed, err
+	s.mu.Unlock()
+	s.onConnectionLost(err)
+	return nil
+}
@@ -0,0 +1,73 @@
+package subscribertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsync "github.com/sony/appsync-client-go"
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func TestSubscriber(t *testing.T) {
+	received := []*graphql.Response{}
+	var lost error
+	s := NewSubscriber(func(r *graphql.Response) { received = append(received, r) }, func(err error) { lost = err })
+
+	if err := s.PushData("subscribeToEcho", "ignored"); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("got: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if s.State() != appsync.SubscriberStarted {
+		t.Errorf("state: %s", s.State())
+	}
+	if err := s.Start(); !errors.Is(err, appsync.ErrSubscriberStarted) || s.State() != appsync.SubscriberStarted {
+		t.Errorf("got: %v, state: %s", err, s.State())
+	}
+	if err := s.PushData("subscribeToEcho", "Hi, AppSync!"); err != nil {
+		t.Fatal(err)
+	}
+	data := new(string)
+	if len(received) != 1 || received[0].DataAs(data) != nil || *data != "Hi, AppSync!" {
+		t.Errorf("got: %v", received)
+	}
+
+	cause := errors.New("connection lost")
+	if err := s.LoseConnection(cause); err != nil {
+		t.Fatal(err)
+	}
+	if lost != cause || s.State() != appsync.SubscriberFailed || s.Err() != cause {
+		t.Errorf("got: %v, %s, %v", lost, s.State(), s.Err())
+	}
+
+	if err := s.StopContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.State() != appsync.SubscriberStopped || s.Starts() != 2 || s.Stops() != 1 {
+		t.Errorf("got: %s, %d, %d", s.State(), s.Starts(), s.Stops())
+	}
+	if err := s.Start(); !errors.Is(err, appsync.ErrSubscriberStopped) || s.State() != appsync.SubscriberStopped {
+		t.Errorf("got: %v, %s", err, s.State())
+	}
+}
+
+func TestSubscriber_StartContext(t *testing.T) {
+	s := NewSubscriber(func(*graphql.Response) {}, func(error) {})
+	startErr := errors.New("unable to connect")
+	s.FailStart(startErr)
+	if err := s.Start(); err != startErr || s.State() != appsync.SubscriberFailed {
+		t.Errorf("got: %v, %s", err, s.State())
+	}
+	s.FailStart(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.StartContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got: %v", err)
+	}
+	if err := s.Start(); err != nil || s.State() != appsync.SubscriberStarted {
+		t.Errorf("got: %v, %s", err, s.State())
+	}
+}
@@ -0,0 +1,238 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+// SubscriptionTransport selects the transport of a SubscriptionHandle.
+type SubscriptionTransport string
+
+const (
+	// TransportMQTT subscribes with MQTT over websockets through Subscriber.
+	TransportMQTT SubscriptionTransport = "mqtt"
+	// TransportWebSocket subscribes with pure websockets through PureWebSocketSubscriber.
+	TransportWebSocket SubscriptionTransport = "websocket"
+	// TransportCompare subscribes with both transports side by side, delivering every message once per transport.
+	TransportCompare SubscriptionTransport = "compare"
+)
+
+const defaultEventBufferSize = 64
+
+// SubscriptionEvent is a message or a connection loss delivered by a SubscriptionHandle.
+type SubscriptionEvent struct {
+	Transport SubscriptionTransport
+	Response  *graphql.Response
+	Err       error
+}
+
+// SubscriptionConfig configures the transport of a SubscriptionHandle.
+type SubscriptionConfig struct {
+	// Transport is TransportMQTT, TransportWebSocket or TransportCompare.
+	Transport SubscriptionTransport
+	// RealtimeEndpoint is the realtime endpoint of pure websockets.
+	RealtimeEndpoint string
+	// WebSocketOptions are applied to the PureWebSocketSubscriber.
+	WebSocketOptions []PureWebSocketSubscriberOption
+	// SubscriberOptions are applied to the MQTT Subscriber.
+	SubscriberOptions []SubscriberOption
+	// BufferSize is the capacity of the events channel, 64 by default.
+	BufferSize int
+}
+
+type transportStarter struct {
+	transport SubscriptionTransport
+	start     func(ctx context.Context, onReceive func(*graphql.Response), onConnectionLost func(error)) (func(), error)
+}
+
+// SubscriptionHandle is a subscription controlled in the same way regardless of its transport,
+// delivering its messages and connection losses as events.
+type SubscriptionHandle interface {
+	RealtimeSubscriber
+	// Events returns the channel of the messages and connection losses of the subscription,
+	// which is closed by Stop.
+	Events() <-chan SubscriptionEvent
+}
+
+var _ SubscriptionHandle = (*subscriptionHandle)(nil)
+
+type subscriptionHandle struct {
+	starters []transportStarter
+	events   chan SubscriptionEvent
+	done     chan struct{}
+	stopOnce sync.Once
+	status   subscriberStatus
+
+	mu      sync.Mutex
+	started bool
+	stops   []func()
+
+	// sendMu is held while sending events, so that the channel is not closed meanwhile.
+	sendMu sync.RWMutex
+	closed bool
+}
+
+// NewSubscriptionHandle returns a SubscriptionHandle of the subscription request over the configured transport,
+// so that the transport can be switched by configuration.
+func (c *Client) NewSubscriptionHandle(request graphql.PostRequest, cfg SubscriptionConfig) (SubscriptionHandle, error) {
+	mqtt := transportStarter{TransportMQTT, func(ctx context.Context, onReceive func(*graphql.Response), onConnectionLost func(error)) (func(), error) {
+		s, err := c.NewSubscriber(request, onReceive, onConnectionLost, cfg.SubscriberOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.StartContext(ctx); err != nil {
+			return nil, err
+		}
+		return s.Stop, nil
+	}}
+	websocket := transportStarter{TransportWebSocket, func(ctx context.Context, onReceive func(*graphql.Response), onConnectionLost func(error)) (func(), error) {
+		p := NewPureWebSocketSubscriber(cfg.RealtimeEndpoint, request, onReceive, onConnectionLost, cfg.WebSocketOptions...)
+		if err := p.StartContext(ctx); err != nil {
+			p.Stop()
+			return nil, err
+		}
+		return p.Stop, nil
+	}}
+
+	starters := []transportStarter{}
+	switch cfg.Transport {
+	case TransportMQTT:
+		starters = append(starters, mqtt)
+	case TransportWebSocket:
+		starters = append(starters, websocket)
+	case TransportCompare:
+		starters = append(starters, mqtt, websocket)
+	default:
+		return nil, fmt.Errorf("unsupported subscription transport %q", cfg.Transport)
+	}
+	if cfg.Transport != TransportMQTT && len(cfg.RealtimeEndpoint) == 0 {
+		return nil, errors.New("realtime endpoint is required for pure websockets")
+	}
+
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	return &subscriptionHandle{
+		starters: starters,
+		events:   make(chan SubscriptionEvent, size),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts the subscription on every configured transport.
+func (h *subscriptionHandle) Start() error {
+	return h.StartContext(context.Background())
+}
+
+// StartContext is like Start but aborts connecting and subscribing when ctx is done.
+func (h *subscriptionHandle) StartContext(ctx context.Context) error {
+	h.mu.Lock()
+	if h.status.isStopped() {
+		h.mu.Unlock()
+		return ErrSubscriberStopped
+	}
+	if h.started {
+		h.mu.Unlock()
+		return ErrSubscriberStarted
+	}
+	h.started = true
+	h.status.set(SubscriberStarting, nil)
+	h.mu.Unlock()
+
+	stops := []func(){}
+	for _, s := range h.starters {
+		transport := s.transport
+		stop, err := s.start(ctx,
+			func(r *graphql.Response) { h.emit(SubscriptionEvent{Transport: transport, Response: r}) },
+			func(err error) {
+				h.status.set(SubscriberFailed, err)
+				h.emit(SubscriptionEvent{Transport: transport, Err: err})
+			},
+		)
+		if err != nil {
+			slog.Error("unable to start subscription", "transport", transport, "error", err)
+			for _, stop := range stops {
+				stop()
+			}
+			h.mu.Lock()
+			h.started = false
+			h.mu.Unlock()
+			h.status.set(SubscriberFailed, err)
+			return err
+		}
+		stops = append(stops, stop)
+	}
+	h.mu.Lock()
+	if h.status.isStopped() {
+		// Stop was called while starting.
+		h.mu.Unlock()
+		for _, stop := range stops {
+			stop()
+		}
+		return ErrSubscriberStopped
+	}
+	h.stops = stops
+	h.mu.Unlock()
+	h.status.set(SubscriberStarted, nil)
+	return nil
+}
+
+func (h *subscriptionHandle) emit(event SubscriptionEvent) {
+	h.sendMu.RLock()
+	defer h.sendMu.RUnlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.events <- event:
+	case <-h.done:
+	}
+}
+
+// Stop ends the subscription and closes the events channel.
+func (h *subscriptionHandle) Stop() {
+	h.stopOnce.Do(func() {
+		h.mu.Lock()
+		h.status.stop()
+		stops := h.stops
+		h.stops = nil
+		h.mu.Unlock()
+		// Drop pending emits first, since the transports wait for their callbacks to return while stopping.
+		close(h.done)
+		h.sendMu.Lock()
+		h.closed = true
+		close(h.events)
+		h.sendMu.Unlock()
+		for _, stop := range stops {
+			stop()
+		}
+	})
+}
+
+// StopContext is like Stop but returns ctx.Err() when ctx is done before the subscription has ended.
+func (h *subscriptionHandle) StopContext(ctx context.Context) error {
+	return stopContext(ctx, h.Stop)
+}
+
+// State returns the current state of the subscription.
+func (h *subscriptionHandle) State() SubscriberState {
+	state, _ := h.status.get()
+	return state
+}
+
+// Err returns the error which failed starting or a transport last, or nil.
+func (h *subscriptionHandle) Err() error {
+	_, err := h.status.get()
+	return err
+}
+
+// Events returns the channel of the messages and connection losses of the subscription.
+func (h *subscriptionHandle) Events() <-chan SubscriptionEvent {
+	return h.events
+}
@@ -0,0 +1,147 @@
+package appsync
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/internal/appsynctest"
+)
+
+func TestClient_NewSubscriptionHandle(t *testing.T) {
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	subscription := graphql.PostRequest{Query: `subscription SubscribeToEcho() { subscribeToEcho }`}
+	mutation := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).Var("message", "Hi, AppSync!").MustBuild()
+	realtimeEndpoint := strings.Replace(server.URL, "http", "ws", 1)
+
+	tests := []struct {
+		transport SubscriptionTransport
+		want      []SubscriptionTransport
+	}{
+		{transport: TransportMQTT, want: []SubscriptionTransport{TransportMQTT}},
+		{transport: TransportWebSocket, want: []SubscriptionTransport{TransportWebSocket}},
+		{transport: TransportCompare, want: []SubscriptionTransport{TransportMQTT, TransportWebSocket}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.transport), func(t *testing.T) {
+			h, err := client.NewSubscriptionHandle(subscription, SubscriptionConfig{
+				Transport:        tt.transport,
+				RealtimeEndpoint: realtimeEndpoint,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Start(); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Start(); err == nil {
+				t.Error("starting twice should fail")
+			}
+			if _, err := client.Post(mutation); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[SubscriptionTransport]bool{}
+			for range tt.want {
+				select {
+				case e := <-h.Events():
+					data := new(string)
+					if e.Err != nil || e.Response.DataAs(data) != nil || *data != "Hi, AppSync!" {
+						t.Errorf("got: %+v", e)
+					}
+					got[e.Transport] = true
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for an event")
+				}
+			}
+			for _, transport := range tt.want {
+				if !got[transport] {
+					t.Errorf("no event from %s", transport)
+				}
+			}
+
+			h.Stop()
+			h.Stop()
+			if _, ok := <-h.Events(); ok {
+				t.Error("events should be closed after stop")
+			}
+		})
+	}
+}
+
+func TestClient_NewSubscriptionHandle_invalidConfig(t *testing.T) {
+	client := NewClient(NewGraphQLClient(graphql.NewClient("http://localhost")))
+	tests := []struct {
+		name string
+		cfg  SubscriptionConfig
+	}{
+		{name: "unknown transport", cfg: SubscriptionConfig{Transport: "sse"}},
+		{name: "websocket without endpoint", cfg: SubscriptionConfig{Transport: TransportWebSocket}},
+		{name: "compare without endpoint", cfg: SubscriptionConfig{Transport: TransportCompare}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.NewSubscriptionHandle(graphql.PostRequest{}, tt.cfg); err == nil {
+				t.Error("an invalid config should be rejected")
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandle_StopWithFullBuffer(t *testing.T) {
+	delay := subscriptionDelay
+	subscriptionDelay = 0
+	defer func() { subscriptionDelay = delay }()
+
+	server := appsynctest.NewAppSyncEchoServer()
+	defer server.Close()
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	subscription := graphql.PostRequest{Query: `subscription SubscribeToEcho() { subscribeToEcho }`}
+	mutation := graphql.NewRequest(`mutation Echo($message: String!) { echo(message: $message) }`).Var("message", "Hi, AppSync!").MustBuild()
+
+	for _, transport := range []SubscriptionTransport{TransportMQTT, TransportWebSocket} {
+		t.Run(string(transport), func(t *testing.T) {
+			h, err := client.NewSubscriptionHandle(subscription, SubscriptionConfig{
+				Transport:        transport,
+				RealtimeEndpoint: strings.Replace(server.URL, "http", "ws", 1),
+				BufferSize:       1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Start(); err != nil {
+				t.Fatal(err)
+			}
+			// Nobody reads the events, so the transport blocks delivering the second message.
+			for i := 0; i < 3; i++ {
+				if _, err := client.Post(mutation); err != nil {
+					t.Fatal(err)
+				}
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for len(h.Events()) != cap(h.Events()) {
+				if time.Now().After(deadline) {
+					t.Fatal("timed out waiting for the buffer to fill")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(100 * time.Millisecond)
+
+			done := make(chan struct{})
+			go func() {
+				h.Stop()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Stop should not wait for the full events buffer")
+			}
+		})
+	}
+}
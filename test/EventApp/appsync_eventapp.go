@@ -3,20 +3,19 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"log/slog"
 	"os"
-	"strings"
 
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	appsync "github.com/sony/appsync-client-go"
 	"github.com/sony/appsync-client-go/graphql"
+	"github.com/sony/appsync-client-go/scalars"
 )
 
 type channel struct {
-	Name string `json:"name"`
-	Data string `json:"data"`
+	Name string          `json:"name"`
+	Data scalars.AWSJSON `json:"data"`
 }
 
 func main() {
@@ -28,11 +27,21 @@ func main() {
 	slog.SetDefault(slog.New(handle))
 
 	var (
-		region = flag.String("region", "", "AppSync API region")
+		region = flag.String("region", "", "AppSync API region, required for custom domains")
 		url    = flag.String("url", "", "AppSync API URL")
 	)
 	flag.Parse()
 
+	var opts []appsync.EndpointOption
+	if len(*region) != 0 {
+		opts = append(opts, appsync.WithEndpointRegion(*region))
+	}
+	endpoint, err := appsync.ResolveEndpoint(*url, opts...)
+	if err != nil {
+		slog.Error("unable to resolve endpoint", "error", err)
+		os.Exit(1)
+	}
+
 	ctx := context.TODO()
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
@@ -45,18 +54,18 @@ func main() {
 		os.Exit(1)
 	}
 	signer := v4.NewSigner()
-	opt := appsync.WithIAMAuthorizationV2(signer, creds, *region, *url)
-	sOpt := appsync.WithIAMV2(signer, creds, *region, *url)
+	opt := appsync.WithIAMAuthorizationV2(signer, creds, endpoint.Region, endpoint.GraphQLURL)
+	sOpt := appsync.WithIAMV2(signer, creds, endpoint.Region, endpoint.GraphQLURL)
 
 	client := appsync.NewClient(appsync.NewGraphQLClient(graphql.NewClient(*url)), opt)
 
 	name := "name"
-	data := `{\"key\": \"value\"}`
+	data := scalars.AWSJSON(`{"key": "value"}`)
 
 	ch := make(chan *graphql.Response)
 	defer close(ch)
 
-	s := subscribe(*url, sOpt, name, ch)
+	s := subscribe(endpoint.RealtimeURL, sOpt, name, ch)
 
 	slog.Info("start subscribe")
 	if err := s.Start(); err != nil {
@@ -70,16 +79,19 @@ func main() {
 }
 
 func publish(c *appsync.Client, ch channel) *graphql.Response {
-	mutation := fmt.Sprintf(`
-mutation {
-	publish(name: "%s", data: "%s") {
-        name
-		data
-    }
-}`, ch.Name, ch.Data)
-	res, err := c.Post(graphql.PostRequest{
-		Query: mutation,
-	})
+	mutation, err := graphql.Mutation("Publish").
+		Var("name", "String!", ch.Name).
+		Var("data", "AWSJSON!", ch.Data).
+		Select(graphql.Field("publish").
+			Arg("name", graphql.Variable("name")).
+			Arg("data", graphql.Variable("data")).
+			Select(graphql.Field("name"), graphql.Field("data"))).
+		Build()
+	if err != nil {
+		slog.Error("unable to build mutation", "error", err)
+		os.Exit(1)
+	}
+	res, err := c.Post(mutation)
 	if err != nil {
 		slog.Error("unable to create postRequest", "error", err)
 		os.Exit(1)
@@ -87,18 +99,17 @@ mutation {
 	return res
 }
 
-func subscribe(url string, opt appsync.PureWebSocketSubscriberOption, name string, ch chan *graphql.Response) *appsync.PureWebSocketSubscriber {
-	subscription := fmt.Sprintf(`
-subscription {
-	subscribe(name: "%s"){
-		name
-		data
-	}
-}`, name)
-	subreq := graphql.PostRequest{
-		Query: subscription,
+func subscribe(realtime string, opt appsync.PureWebSocketSubscriberOption, name string, ch chan *graphql.Response) *appsync.PureWebSocketSubscriber {
+	subreq, err := graphql.Subscription("Subscribe").
+		Var("name", "String!", name).
+		Select(graphql.Field("subscribe").
+			Arg("name", graphql.Variable("name")).
+			Select(graphql.Field("name"), graphql.Field("data"))).
+		Build()
+	if err != nil {
+		slog.Error("unable to build subscription", "error", err)
+		os.Exit(1)
 	}
-	realtime := strings.Replace(strings.Replace(url, "https", "wss", 1), "appsync-api", "appsync-realtime-api", 1)
 	return appsync.NewPureWebSocketSubscriber(realtime, subreq,
 		func(r *graphql.Response) { ch <- r },
 		func(err error) {
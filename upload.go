@@ -0,0 +1,81 @@
+package appsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+type presignedUploader interface {
+	UploadPresigned(ctx context.Context, target graphql.PresignedUpload, file graphql.Upload) error
+}
+
+// PresignedUploadField returns an extractor reading the presigned upload target at the given path in Response.Data.
+// The field is either a presigned PUT URL string or an object with url, and optionally method, headers and fields.
+func PresignedUploadField(path ...string) func(*graphql.Response) (*graphql.PresignedUpload, error) {
+	return func(r *graphql.Response) (*graphql.PresignedUpload, error) {
+		v, ok := lookup(r.Data, path)
+		if !ok || v == nil {
+			return nil, fmt.Errorf("presigned upload is missing at %v", path)
+		}
+		switch t := v.(type) {
+		case string:
+			return &graphql.PresignedUpload{URL: t}, nil
+		case map[string]interface{}:
+			u, _ := t["url"].(string)
+			if len(u) == 0 {
+				return nil, fmt.Errorf("presigned upload url is missing at %v", path)
+			}
+			target := &graphql.PresignedUpload{URL: u, Header: http.Header{}, Fields: map[string]string{}}
+			target.Method, _ = t["method"].(string)
+			if headers, ok := t["headers"].(map[string]interface{}); ok {
+				for k, v := range headers {
+					target.Header.Set(k, fmt.Sprint(v))
+				}
+			}
+			if fields, ok := t["fields"].(map[string]interface{}); ok {
+				for k, v := range fields {
+					target.Fields[k] = fmt.Sprint(v)
+				}
+			}
+			return target, nil
+		}
+		return nil, fmt.Errorf("presigned upload at %v is invalid", path)
+	}
+}
+
+// PostWithPresignedUpload runs the mutation obtaining a presigned upload target, such as an S3 presigned URL
+// generated by a resolver, and uploads the file to it through the HTTP client of the GraphQL client.
+// The mutation response is returned with a nil error only when the upload succeeded.
+func (c *Client) PostWithPresignedUpload(ctx context.Context, request graphql.PostRequest,
+	target func(*graphql.Response) (*graphql.PresignedUpload, error), file graphql.Upload) (*graphql.Response, error) {
+	uploader, ok := c.graphQLAPI.(presignedUploader)
+	if !ok {
+		return nil, errors.New("the GraphQL client does not support uploads")
+	}
+	if !request.IsMutation() {
+		return nil, errors.New("the request is not a mutation")
+	}
+
+	response, err := c.Post(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Errors != nil && len(*response.Errors) != 0 {
+		return response, fmt.Errorf("unable to obtain presigned upload: %v", *response.Errors)
+	}
+	t, err := target(response)
+	if err != nil {
+		slog.Error("unable to read presigned upload", "error", err, "response", response)
+		return response, err
+	}
+	if err := uploader.UploadPresigned(ctx, *t, file); err != nil {
+		slog.Error("unable to upload file", "error", err)
+		return response, err
+	}
+	return response, nil
+}
@@ -0,0 +1,97 @@
+package appsync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sony/appsync-client-go/graphql"
+)
+
+func TestPresignedUploadField(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{}
+		want    *graphql.PresignedUpload
+		wantErr bool
+	}{
+		{
+			name: "url",
+			data: map[string]interface{}{"createUpload": map[string]interface{}{"uploadURL": "https://s3/key"}},
+			want: &graphql.PresignedUpload{URL: "https://s3/key"},
+		},
+		{
+			name: "object",
+			data: map[string]interface{}{"createUpload": map[string]interface{}{"uploadURL": map[string]interface{}{
+				"url": "https://s3", "method": "POST", "fields": map[string]interface{}{"key": "k"},
+				"headers": map[string]interface{}{"x-amz-acl": "private"},
+			}}},
+			want: &graphql.PresignedUpload{URL: "https://s3", Method: "POST", Fields: map[string]string{"key": "k"},
+				Header: http.Header{"X-Amz-Acl": []string{"private"}}},
+		},
+		{
+			name:    "missing",
+			data:    map[string]interface{}{"createUpload": nil},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			data:    map[string]interface{}{"createUpload": map[string]interface{}{"uploadURL": 1.0}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PresignedUploadField("createUpload", "uploadURL")(&graphql.Response{Data: tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("want: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_PostWithPresignedUpload(t *testing.T) {
+	uploaded := make(chan string, 1)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			b, _ := io.ReadAll(r.Body)
+			uploaded <- r.URL.Path + ":" + string(b)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"data":{"createUpload":{"key":"a.txt","uploadURL":"%s/bucket/a.txt"}}}`, server.URL)
+	}))
+	defer server.Close()
+
+	client := NewClient(NewGraphQLClient(graphql.NewClient(server.URL)))
+	mutation := graphql.PostRequest{Query: "mutation { createUpload(name: \"a.txt\") { key uploadURL } }"}
+	file := graphql.Upload{Size: 5, Reader: strings.NewReader("hello")}
+
+	response, err := client.PostWithPresignedUpload(context.Background(), mutation,
+		PresignedUploadField("createUpload", "uploadURL"), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := FieldKey("createUpload", "key")(response); key != "a.txt" {
+		t.Errorf("key: %s", key)
+	}
+	if got := <-uploaded; got != "/bucket/a.txt:hello" {
+		t.Errorf("uploaded: %s", got)
+	}
+
+	if _, err := client.PostWithPresignedUpload(context.Background(), graphql.PostRequest{Query: "query { a }"},
+		PresignedUploadField("createUpload", "uploadURL"), file); err == nil {
+		t.Error("a query should be rejected")
+	}
+	if _, err := NewClient(&scriptedGraphQLAPI{}).PostWithPresignedUpload(context.Background(), mutation,
+		PresignedUploadField("createUpload", "uploadURL"), file); err == nil {
+		t.Error("a client without upload support should fail")
+	}
+}